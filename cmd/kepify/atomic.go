@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// stdoutPath is the -output that writes to stdout.
+const stdoutPath = "-"
+
+// writeOutput calls write with stdout if path is stdoutPath, and otherwise
+// with an atomicFile that is only committed to path once write succeeds.
+func writeOutput(path string, write func(io.Writer) error) error {
+	if path == stdoutPath {
+		return write(os.Stdout)
+	}
+	file, err := createAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if err := write(file); err != nil {
+		return err
+	}
+	return file.Commit()
+}
+
+// atomicFile is written to a temporary file in the same directory as its
+// destination and only renamed into place by Commit, so a failed or
+// interrupted run never leaves a partially written output behind.
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+// createAtomic creates the temporary file of path. Unlike os.CreateTemp,
+// which only grants the owner access, it is created with mode 0666 like
+// os.Create, so that the umask applies to a new output.
+func createAtomic(path string) (*atomicFile, error) {
+	prefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".")
+	for try := 0; ; try++ {
+		file, err := os.OpenFile(prefix+strconv.FormatUint(uint64(rand.Uint32()), 10), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && try < 10000 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &atomicFile{File: file, path: path}, nil
+	}
+}
+
+// Commit closes the temporary file and renames it over the destination. An
+// existing destination keeps its mode.
+func (f *atomicFile) Commit() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if info, err := os.Stat(f.path); err == nil {
+		if err := os.Chmod(f.Name(), info.Mode().Perm()); err != nil {
+			os.Remove(f.Name())
+			return err
+		}
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort discards the temporary file. It is a no-op after Commit.
+func (f *atomicFile) Abort() {
+	f.File.Close()
+	os.Remove(f.Name())
+}
+
+// backupFile copies path to path.bak, replacing any older backup, so the
+// previous output survives a bad run. The output itself is left in place
+// until the new one is committed over it. It does nothing if path does not
+// exist yet.
+func backupFile(path string) error {
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return writeOutput(path+".bak", func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// envPrefix is prepended to the environment variable for each flag.
+const envPrefix = "KEPIFY_"
+
+// envName returns the environment variable that sets the default of the
+// named flag, e.g. KEPIFY_MIN_WORDS for -min-words.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(flagName, "-", "_", -1))
+}
+
+// envFlags returns the flags of fs that can be set from the environment.
+// Repeatable flags are left out because a value from the environment could
+// not be told apart from values given on the command line.
+func envFlags(fs *flag.FlagSet) []*flag.Flag {
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, repeated := f.Value.(*stringList); !repeated {
+			flags = append(flags, f)
+		}
+	})
+	return flags
+}
+
+// setFlagsFromEnv sets every flag of fs that has an environment variable
+// from lookup. It must be called before fs is parsed so that flags given on
+// the command line take precedence.
+func setFlagsFromEnv(fs *flag.FlagSet, lookup func(string) (string, bool)) error {
+	for _, f := range envFlags(fs) {
+		value, ok := lookup(envName(f.Name))
+		if !ok {
+			continue
+		}
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %v", value, envName(f.Name), err)
+		}
+	}
+	return nil
+}
@@ -17,31 +17,242 @@ limitations under the License.
 package main
 
 import (
-	"crypto/md5"
+	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"k8s.io/enhancements/pkg/kepval/keps"
+	"k8s.io/enhancements/pkg/kepval/keps/validations"
 )
 
+// formats are the supported values of -format.
+var formats = []string{"json", "jsonl", "yaml", "sqlite", "markdown-index", "search-index", "dot", "html", "toc", "ndjson-stream"}
+
+func validFormat(format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// formatExtensions maps output file extensions to the format that is used
+// for them when -format is not given.
+var formatExtensions = map[string]string{
+	".json":   "json",
+	".jsonl":  "jsonl",
+	".yaml":   "yaml",
+	".yml":    "yaml",
+	".db":     "sqlite",
+	".sqlite": "sqlite",
+	".md":     "markdown-index",
+	".dot":    "dot",
+	".gv":     "dot",
+	".html":   "html",
+}
+
+// inferFormat returns the output format for filePath based on its
+// extension. ok is false if the extension is not known, in which case json
+// is returned.
+func inferFormat(filePath string) (format string, ok bool) {
+	format, ok = formatExtensions[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return "json", false
+	}
+	return format, true
+}
+
+// progress is where messages about the progress of a run are written.
+var progress io.Writer = os.Stdout
+
+// warnings is where validation warnings are written. Errors always go to
+// stderr so wrappers can tell the two apart.
+var warnings io.Writer = os.Stdout
+
+// fix is set by -fix to rewrite the KEP files that list their owning SIG in
+// participating-sigs before validating them.
+var fix = false
+
+// normalizeLists is set by -normalize-lists to also rewrite flow lists as
+// block lists when fixing KEP files.
+var normalizeLists = false
+
+// stream, if set by -format ndjson-stream, is called by parseFiles with
+// every KEP as soon as it is parsed and validated, before its body is
+// dropped. An error is handled like a validation error of the KEP.
+var stream func(kep *keps.Proposal) error
+
+// noSortLists is set by -no-sort-lists to keep the order of the authors,
+// reviewers, approvers and participating-sigs when fixing KEP files.
+var noSortLists = false
+
 func Usage() {
 	fmt.Fprintf(os.Stderr, `
-Usage: %s [-dir <kep-directory>] [-output <path-to-json-file>]
+Usage: %s [-dir <kep-directory>] [-output <path-to-output-file>] [-format <format>] [-stats] [-group-by <field>] [-print-fields] [-sig-summary] [-count] [-status <status>]... [-exclude-status <status>]...
+       [-sig <sig>]... [-feature-gate <name>] [-only <path-or-number>] [-head-limit <n>] [-warn-output <file>] [-min-words <count>] [-allow-key <key>]... [-relative-paths]
+       [-base-url <url>] [-check-body-people] [-backup] [-disable-keyword <word>]...
+       [-owners <OWNERS-file>] [-check-code-languages] [-code-language <language>]... [-check-code-examples] [-todo-marker <word>]...
+       [-rationale-heading <heading>]... [-prr-heading <heading>]... [-follow-symlinks] [-include-ignored] [-ignored-non-fatal] [-no-fail] [-no-body]
+       [-search-plain-text] [-strict] [-fix] [-number-width <digits>] [-max-kep-number <n>] [-strict-yaml]
+       [-normalize-lists] [-no-sort-lists] [-append] [-output-by-status <dir>] [-skip-empty-statuses] [-date-skew <duration>]
+       [-timeout <duration>] [-implementable-reviewers-severity warning|error] [-strict-milestone] [-strict-milestone-severity warning|error]
+       [-enable <validator>,...] [-version] [<kep-file>...]
 Command line flags override config values.
+Given KEP files under -dir, such as the files changed in a commit, only those are parsed instead of every KEP.
+-output - writes to stdout, e.g. %[1]s -output - | gzip > keps.json.gz
+-format ndjson-stream writes each KEP as a jsonl line as soon as it is parsed, in path order, e.g. %[1]s -format ndjson-stream -output - | jq .title
+Flags that are not repeatable default to the value of an environment variable:
 `, os.Args[0])
-	flag.PrintDefaults()
+	for _, f := range envFlags(flag.CommandLine) {
+		if !hiddenFlags[f.Name] {
+			fmt.Fprintf(os.Stderr, "  %s sets -%s\n", envName(f.Name), f.Name)
+		}
+	}
+	printVisibleDefaults(flag.CommandLine)
 }
 
 func main() {
 	dirPath := flag.String("dir", "keps", "root directory for the KEPs")
-	filePath := flag.String("output", "keps.json", "output json file")
+	filePath := flag.String("output", "keps.json", "output file, or - for stdout")
+	outputByStatus := flag.String("output-by-status", "", "instead of -output, write the KEPs of each status to a file of its own in this directory, such as implementable.json")
+	skipEmptyStatuses := flag.Bool("skip-empty-statuses", false, "with -output-by-status, do not write files for statuses without KEPs")
+	appendOutput := flag.Bool("append", false, "merge the KEPs into the existing json output file instead of replacing it, the freshly parsed KEP winning over one with the same owning SIG and title")
+	backup := flag.Bool("backup", false, "copy an existing output file to <output>.bak before writing a new one")
+	format := flag.String("format", "", "output format, one of: "+strings.Join(formats, ", ")+" (default inferred from the -output extension, otherwise json)")
+	minWords := flag.Int("min-words", keps.MinWordCount, "warn about KEP bodies with fewer words than this")
+	stats := flag.Bool("stats", false, "print the number of KEPs by status and SIG instead of writing the output")
+	groupBy := flag.String("group-by", "", "print the number of KEPs for each value of one field, one of: "+strings.Join(groupByNames(), ", ")+", as a table instead of writing the output, implies -stats")
+	printFields := flag.Bool("print-fields", false, "print every metadata key used by the KEPs with the number of KEPs using it instead of writing the output")
+	sigSummary := flag.Bool("sig-summary", false, "print the number of KEPs of each SIG by status as CSV instead of writing the output")
+	count := flag.Bool("count", false, "print the number of KEPs instead of writing the output")
+	version := flag.Bool("version", false, "print the version of kepify and exit")
+	var statuses, excludedStatuses, sigs stringList
+	flag.Var(&statuses, "status", "only include KEPs with this status, can be repeated")
+	flag.Var(&excludedStatuses, "exclude-status", "leave out KEPs with this status, applied after -status, can be repeated")
+	flag.Var(&sigs, "sig", "only include KEPs owned by this SIG, can be repeated")
+	featureGate := flag.String("feature-gate", "", "only include KEPs that declare this feature gate")
+	warnOutput := flag.String("warn-output", "", "write validation warnings to this file instead of stdout")
+	headLimit := flag.Int("head-limit", 0, "only parse the first N KEP files in path order, for smoke tests (default all)")
+	only := flag.String("only", "", "only parse the KEP with this path, relative to -dir, or KEP number")
+	baseURL := flag.String("base-url", "", "link the KEPs in the markdown-index, search-index, html and toc outputs below this URL, e.g. https://github.com/kubernetes/enhancements/blob/master/keps")
+	relativePaths := flag.Bool("relative-paths", false, "include the path of each KEP, relative to -dir, in the json and sqlite outputs")
+	var rationaleHeadings stringList
+	flag.Var(&rationaleHeadings, "rationale-heading", "heading that explains why a KEP was deferred, rejected or withdrawn, can be repeated (default "+strings.Join(keps.RationaleHeadings, ", ")+")")
+	var disableKeywords stringList
+	flag.Var(&disableKeywords, "disable-keyword", "word that KEPs with disable-supported should mention, can be repeated (default "+strings.Join(keps.DisableKeywords, ", ")+")")
+	var todoMarkers stringList
+	flag.Var(&todoMarkers, "todo-marker", "word that implemented and stable KEPs should no longer contain, can be repeated (default "+strings.Join(keps.TodoMarkers, ", ")+")")
+	var prrHeadings stringList
+	flag.Var(&prrHeadings, "prr-heading", "heading of the production readiness questionnaire required for beta and stable KEPs, can be repeated (default "+strings.Join(keps.PRRHeadings, ", ")+")")
+	includeIgnored := flag.Bool("include-ignored", false, "also parse the template, FAQ and README files that are skipped by default")
+	noFail := flag.Bool("no-fail", false, "report every KEP that fails to parse or validate and every replacement cycle, but skip those KEPs and exit 0 instead of failing")
+	ignoredNonFatal := flag.Bool("ignored-non-fatal", false, "with -include-ignored, report the files that are skipped by default and fail to parse instead of exiting")
+	followSymlinks := flag.Bool("follow-symlinks", false, "descend into symlinked directories under -dir")
+	noBody := flag.Bool("no-body", false, "leave the markdown body of each KEP out of the output")
+	searchPlainText := flag.Bool("search-plain-text", false, "strip the markdown syntax from the bodies in the search-index output")
+	timeout := flag.Duration("timeout", 0, "give up parsing after this long, e.g. 30s (default no timeout)")
+	flag.BoolVar(&keps.CheckCodeExamples, "check-code-examples", false, "warn about fenced yaml and json code blocks that do not parse")
+	flag.BoolVar(&keps.CheckCodeLanguages, "check-code-languages", false, "warn about fenced code blocks that name a language not in -code-language")
+	var codeLanguages stringList
+	flag.Var(&codeLanguages, "code-language", "language that fenced code blocks may name, can be repeated (default "+strings.Join(keps.CodeLanguages, ", ")+")")
+	flag.BoolVar(&keps.Strict, "strict", false, "fail on style problems that many existing KEPs still have, such as participating-sigs listing the owning SIG")
+	flag.BoolVar(&fix, "fix", false, "remove the owning SIG from the participating-sigs of each KEP file that lists it, replace typographic quotes in its metadata with straight ones and sort its authors, reviewers, approvers and participating-sigs, leaving the rest of the file as is, and print a git mv command for each KEP path whose number is not zero padded")
+	flag.BoolVar(&normalizeLists, "normalize-lists", false, "with -fix, rewrite the metadata lists written as [a, b] as one entry per line")
+	flag.BoolVar(&noSortLists, "no-sort-lists", false, "with -fix, keep the order of the authors, reviewers, approvers and participating-sigs instead of sorting them alphabetically")
+	flag.DurationVar(&keps.DateSkew, "date-skew", keps.DateSkew, "how far in the future a last-updated date may be before it is reported")
+	flag.IntVar(&keps.NumberWidth, "number-width", keps.NumberWidth, "number of digits that KEP number prefixes of paths, such as 0015-dry-run.md, should be zero padded to")
+	flag.IntVar(&keps.MaxKEPNumber, "max-kep-number", keps.MaxKEPNumber, "highest kep-number that the metadata of a KEP may record")
+	flag.BoolVar(&keps.CheckBodyPeople, "check-body-people", false, "warn about people mentioned in the Reviewers and Approvers sections of a KEP that its metadata does not list")
+	flag.Var(&keps.ImplementableReviewersSeverity, "implementable-reviewers-severity", "how to report implementable KEPs without reviewers, warning or error")
+	flag.Var(&keps.AuthorsSeverity, "authors-severity", "how to report KEPs without authors, warning or error")
+	flag.BoolVar(&keps.StrictMilestone, "strict-milestone", false, "report implementable and implemented KEPs that set neither latest-milestone nor milestone")
+	flag.Var(&keps.StrictMilestoneSeverity, "strict-milestone-severity", "how -strict-milestone reports KEPs without a milestone, warning or error")
+	ownersFile := flag.String("owners", "", "warn about authors, reviewers, approvers and editors that are not an approver or reviewer in this OWNERS file")
+	// for development use, not listed in the usage message
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile of parsing the KEPs to this file")
+	memProfile := flag.String("memprofile", "", "write a heap profile taken after parsing the KEPs to this file")
+	var allowedKeys stringList
+	flag.Var(&allowedKeys, "allow-key", "metadata key that is allowed even though it is not modeled, can be repeated")
+	strictYAML := flag.Bool("strict-yaml", false, "reject every metadata key that is not modeled, including those allowed by -allow-key, with its line")
+	enable := flag.String("enable", "", "comma separated list of validators to run, any of: "+strings.Join(keps.Validators(), ", ")+" (default all)")
 
 	flag.Usage = Usage
+	if err := setFlagsFromEnv(flag.CommandLine, os.LookupEnv); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 	flag.Parse()
+	if *version {
+		fmt.Printf("kepify %s\n", keps.BuildInfo())
+		return
+	}
+	keps.MinWordCount = *minWords
+	if len(rationaleHeadings) > 0 {
+		keps.RationaleHeadings = rationaleHeadings
+	}
+	if len(prrHeadings) > 0 {
+		keps.PRRHeadings = prrHeadings
+	}
+	if len(disableKeywords) > 0 {
+		keps.DisableKeywords = disableKeywords
+	}
+	if len(todoMarkers) > 0 {
+		keps.TodoMarkers = todoMarkers
+	}
+	if len(codeLanguages) > 0 {
+		keps.CodeLanguages = codeLanguages
+	}
+	if *ownersFile != "" {
+		owners, err := readOwners(*ownersFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not read the OWNERS file: %v\n", err)
+			os.Exit(1)
+		}
+		keps.RequiredOwners = owners
+	}
+	if *enable != "" {
+		if err := keps.EnableValidators(strings.Split(*enable, ",")...); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -enable: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *count || *sigSummary || *stats || *printFields || *groupBy != "" {
+		// keep stdout for the count, the CSV or the report alone
+		progress = os.Stderr
+	}
+	if *groupBy != "" {
+		if groupSelector(*groupBy) == nil {
+			fmt.Fprintf(os.Stderr, "unknown -group-by %q, must be one of: %s\n", *groupBy, strings.Join(groupByNames(), ", "))
+			os.Exit(1)
+		}
+		*stats = true
+	}
+	if *count || *stats || *printFields || *sigSummary {
+		// keep the report on stdout readable
+		warnings = os.Stderr
+	}
+	if *warnOutput != "" {
+		file, err := os.Create(*warnOutput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not create the warnings file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		warnings = file
+	}
 
 	if len(*dirPath) == 0 {
 		fmt.Fprintf(os.Stderr, "please specify the root directory for KEPs using '--dir'\n")
@@ -53,137 +264,1002 @@ func main() {
 	}
 
 	if len(*filePath) == 0 {
-		fmt.Fprintf(os.Stderr, "please specify the file path for the output json using '--output'\n")
+		fmt.Fprintf(os.Stderr, "please specify the file path for the output using '--output'\n")
 		os.Exit(1)
 	}
-
-	// Find all the keps
-	files, err := findMarkdownFiles(dirPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "unable to find markdown files: %v\n", err)
+	if *filePath == stdoutPath {
+		// keep stdout for the output alone
+		progress = os.Stderr
+		warnings = os.Stderr
+	}
+	if *format == "" && (*filePath == stdoutPath || *outputByStatus != "") {
+		*format = "json"
+	}
+	if *format == "" {
+		var ok bool
+		if *format, ok = inferFormat(*filePath); !ok {
+			fmt.Fprintf(os.Stderr, "cannot infer the output format from %q, writing json\n", *filePath)
+		}
+	}
+	if !validFormat(*format) {
+		fmt.Fprintf(os.Stderr, "unknown output format %q, must be one of: %s\n", *format, strings.Join(formats, ", "))
+		os.Exit(1)
+	}
+	if *appendOutput && (*format != "json" || *filePath == stdoutPath || *outputByStatus != "") {
+		fmt.Fprintf(os.Stderr, "-append only works with the json output written to a file\n")
 		os.Exit(1)
 	}
-	if len(files) == 0 {
-		fmt.Fprintf(os.Stderr, "did not find any KEPs\n")
+	if *format == "ndjson-stream" && (*outputByStatus != "" || *count || *stats || *printFields || *sigSummary) {
+		fmt.Fprintf(os.Stderr, "-format ndjson-stream cannot be combined with -output-by-status, -count, -stats, -group-by, -print-fields or -sig-summary\n")
 		os.Exit(1)
 	}
+	if *format == "sqlite" && *filePath == stdoutPath {
+		fmt.Fprintf(os.Stderr, "the sqlite output cannot be written to stdout\n")
+		os.Exit(1)
+	}
+
+	// Find all the keps
+	fsys := os.DirFS(*dirPath)
+	var files []string
+	var err error
+	if flag.NArg() > 0 {
+		if files, err = kepFiles(fsys, *dirPath, flag.Args(), *includeIgnored); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if len(files) == 0 {
+			// such as a commit that only changes other files
+			fmt.Fprintf(progress, "none of the given files is a KEP\n")
+			return
+		}
+	} else {
+		if files, err = findMarkdownFiles(fsys, *followSymlinks, *includeIgnored); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to find markdown files: %v\n", err)
+			os.Exit(1)
+		}
+		if len(files) == 0 {
+			fmt.Fprintf(os.Stderr, "did not find any KEPs\n")
+			os.Exit(1)
+		}
+	}
+	if *only != "" {
+		if files, err = selectKEP(files, *dirPath, *only); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *headLimit > 0 {
+		files = headFiles(files, *headLimit)
+	}
+	// the KEPs given as arguments or with -only are checked against the rest
+	// of -dir, while -head-limit only ever parses its files
+	narrowed := (flag.NArg() > 0 || *only != "") && *headLimit == 0
 
 	// Parse the files
-	proposals, err := parseFiles(files)
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	parser := &keps.Parser{AllowedKeys: allowedKeys, StrictYAML: *strictYAML}
+	selectProposals := func(proposals keps.Proposals) keps.Proposals {
+		if len(statuses) > 0 {
+			proposals = proposals.FilterByStatus(statuses...)
+		}
+		// applied after -status, so a status that both name is left out
+		if len(excludedStatuses) > 0 {
+			proposals = proposals.ExcludeStatus(excludedStatuses...)
+		}
+		if len(sigs) > 0 {
+			proposals = proposals.FilterBySIG(sigs...)
+		}
+		if *featureGate != "" {
+			proposals = proposals.FilterByFeatureGate(*featureGate)
+		}
+		return proposals
+	}
+	opts := outputOptions{paths: *relativePaths, noBody: *noBody, baseURL: *baseURL, plainText: *searchPlainText}
+	var streamed io.WriteCloser
+	if *format == "ndjson-stream" {
+		if *backup && *filePath != stdoutPath {
+			if err := backupFile(*filePath); err != nil {
+				fmt.Fprintf(os.Stderr, "could not back up the output: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if streamed, err = openStream(*filePath); err != nil {
+			fmt.Fprintf(os.Stderr, "could not open file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(progress, "Output file: %s\n", *filePath)
+		written := map[string]string{}
+		stream = func(kep *keps.Proposal) error {
+			return streamKEP(streamed, selectProposals(keps.Proposals{kep}), written, opts)
+		}
+	}
+	var nonFatal func(name string) bool
+	switch {
+	case *noFail:
+		nonFatal = func(string) bool { return true }
+	case *includeIgnored && *ignoredNonFatal:
+		nonFatal = func(name string) bool {
+			return ignore(path.Base(name))
+		}
+	}
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile)
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not start profiling: %v\n", err)
+		os.Exit(1)
+	}
+	// the streamed KEPs are already written with their body
+	proposals, err := parseFiles(ctx, parser, fsys, *dirPath, files, !*noBody && stream == nil, nonFatal)
+	if err := stopProfiling(); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write the profile: %v\n", err)
+		os.Exit(1)
+	}
+	if unreadable, ok := err.(unreadableFiles); ok {
+		// the other files were parsed, so -no-fail can still write them
+		fmt.Fprintf(os.Stderr, "%v\n", unreadable)
+		if !*noFail {
+			os.Exit(1)
+		}
+	} else if err != nil {
 		fmt.Fprintf(os.Stderr, "error parsing files: %q\n", err)
 		os.Exit(1)
 	}
+	var others keps.Proposals
+	if narrowed {
+		all, err := findMarkdownFiles(fsys, *followSymlinks, *includeIgnored)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to find markdown files: %v\n", err)
+			os.Exit(1)
+		}
+		if others, err = parseOthers(ctx, parser, fsys, all, files); err != nil {
+			fmt.Fprintf(os.Stderr, "error parsing files: %q\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(progress, "checking the selected KEPs against %d other KEPs\n", len(others))
+	}
+	if *headLimit > 0 {
+		fmt.Fprintf(warnings, "replacement cycles and KEPs with the same owning-sig and title were only looked for among the %d files of -head-limit, parse the whole -dir to check against every KEP\n", len(files))
+	}
+	// references are resolved against every parsed KEP, while only the
+	// selected ones are checked and written
+	tree := append(append(keps.Proposals(nil), proposals...), others...)
+	// checked before any output, whose keys are derived from the owning SIG
+	errs := proposals.ValidateOwningSIGs()
+	errs = append(errs, proposals.ValidateReplacementCyclesIn(tree)...)
+	if errs = append(errs, proposals.ValidateUniqueHashesIn(tree)...); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+		if !*noFail {
+			os.Exit(1)
+		}
+	}
+	failures, warns := keps.SplitWarnings(proposals.ValidateNumberPadding())
+	for _, err := range warns {
+		fmt.Fprintf(warnings, "%v\n", err)
+	}
+	for _, err := range failures {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+	if fix {
+		printRenames(proposals, *dirPath)
+	}
+	if len(failures) > 0 && !*noFail {
+		os.Exit(1)
+	}
 
-	// Generate the json output
-	err = printJSONOutput(*filePath, proposals)
-	if err != nil {
+	proposals = selectProposals(proposals)
+	if streamed != nil {
+		if err := streamed.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write the output: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(progress, "Total KEPs: %d\n", len(proposals))
+		return
+	}
+
+	if *count {
+		fmt.Println(proposals.Count())
+		return
+	}
+	if *stats && *groupBy != "" {
+		if err := printGroupedStats(os.Stdout, proposals, *groupBy); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write the stats: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *stats {
+		printStats(proposals)
+		return
+	}
+	if *printFields {
+		printKeyCounts(proposals)
+		return
+	}
+	if *sigSummary {
+		if err := printSIGSummary(os.Stdout, proposals); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write the SIG summary: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Generate the output
+	if *appendOutput {
+		if proposals, err = appendToOutput(*filePath, proposals); err != nil {
+			fmt.Fprintf(os.Stderr, "could not append to the output: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// the written KEPs stand in for the parsed ones with the same path, such
+	// as those that -append replaced
+	opts.tree = append(keps.Proposals(nil), proposals...)
+	written := map[string]bool{}
+	for _, kep := range proposals {
+		written[kep.Filename] = true
+	}
+	for _, kep := range tree {
+		if !written[kep.Filename] {
+			opts.tree.AddProposal(kep)
+		}
+	}
+	if *backup && *filePath != stdoutPath && *outputByStatus == "" {
+		if err := backupFile(*filePath); err != nil {
+			fmt.Fprintf(os.Stderr, "could not back up the output: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *outputByStatus != "" {
+		if err := printByStatus(*outputByStatus, *format, proposals, opts, *skipEmptyStatuses); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write the output by status: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(progress, "Output file: %s\n", *filePath)
+	fmt.Fprintf(progress, "Total KEPs: %d\n", len(proposals))
+	if err := printOutput(*filePath, *format, proposals, opts); err != nil {
 		fmt.Fprintf(os.Stderr, "could not open file: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func findMarkdownFiles(dirPath *string) ([]string, error) {
-	files := []string{}
-	err := filepath.Walk(
-		*dirPath,
-		func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				return nil
+// appendToOutput returns the KEPs of the json output at filePath merged with
+// proposals, see keps.Proposals.Merge, or proposals if there is no output
+// yet.
+func appendToOutput(filePath string, proposals keps.Proposals) (keps.Proposals, error) {
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return proposals, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	existing, err := keps.ReadJSON(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	return existing.Merge(proposals), nil
+}
+
+// printOutput writes proposals to filePath in format.
+func printOutput(filePath, format string, proposals keps.Proposals, opts outputOptions) error {
+	if format == "sqlite" {
+		return printSQLiteOutput(filePath, proposals, opts)
+	}
+	return writeOutput(filePath, func(w io.Writer) error {
+		switch format {
+		case "jsonl":
+			return printJSONLinesOutput(w, proposals, opts)
+		case "yaml":
+			return printYAMLOutput(w, proposals)
+		case "markdown-index":
+			return printMarkdownIndex(w, proposals, opts)
+		case "search-index":
+			return printSearchIndex(w, proposals, opts)
+		case "dot":
+			if opts.tree == nil {
+				return proposals.ToDOT(w)
 			}
-			if ignore(info.Name()) {
+			return proposals.ToDOTIn(w, opts.tree)
+		case "html":
+			return printHTMLReport(w, proposals, opts)
+		case "toc":
+			return printTOC(w, proposals, opts)
+		default:
+			return printJSONOutput(w, proposals, opts)
+		}
+	})
+}
+
+// outputExtensions are the file extensions of the files that -output-by-status
+// writes in each format.
+var outputExtensions = map[string]string{
+	"json":           ".json",
+	"jsonl":          ".jsonl",
+	"yaml":           ".yaml",
+	"sqlite":         ".db",
+	"markdown-index": ".md",
+	"search-index":   ".json",
+	"dot":            ".dot",
+	"html":           ".html",
+	"toc":            ".md",
+}
+
+// printByStatus writes the proposals of each status to a file of its own in
+// dir, such as implementable.json. Statuses without proposals are written
+// as an empty output in format, unless skipEmpty is set.
+func printByStatus(dir, format string, proposals keps.Proposals, opts outputOptions, skipEmpty bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, status := range validations.Statuses() {
+		selected := proposals.FilterByStatus(status)
+		if len(selected) == 0 && skipEmpty {
+			continue
+		}
+		filePath := filepath.Join(dir, status+outputExtensions[format])
+		fmt.Fprintf(progress, "Output file: %s (%d KEPs)\n", filePath, len(selected))
+		if err := printOutput(filePath, format, selected, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readOwners parses the OWNERS file at path.
+func readOwners(path string) (*keps.Owners, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return keps.ParseOwners(file, path)
+}
+
+// stringList is a flag.Value that collects every occurrence of a repeated flag.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// findMarkdownFiles returns the KEP files in fsys as slash separated paths
+// relative to its root. A directory with a kep.yaml is a KEP directory, and
+// only its README.md is returned. Files such as templates that ignore skips
+// are only returned when includeIgnored is set. It only relies on fs.FS, so
+// it works the same for a directory on disk and for KEPs compiled in with
+// go:embed. Symlinked directories are only descended into when
+// followSymlinks is set, in which case directories that were already
+// visited are skipped to avoid cycles.
+func findMarkdownFiles(fsys fs.FS, followSymlinks, includeIgnored bool) ([]string, error) {
+	files := []string{}
+	var visited []fs.FileInfo
+	var walk func(root string) error
+	walk = func(root string) error {
+		return fs.WalkDir(
+			fsys,
+			root,
+			func(name string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				// files below a followed symlink keep their path through
+				// the link rather than the link target
+				if followSymlinks && d.Type()&fs.ModeSymlink != 0 {
+					target, err := fs.Stat(fsys, name)
+					if err != nil {
+						return err
+					}
+					if target.IsDir() {
+						return walk(name)
+					}
+				}
+				if d.IsDir() {
+					info, err := d.Info()
+					if err != nil {
+						return err
+					}
+					for _, dir := range visited {
+						if os.SameFile(dir, info) {
+							return fs.SkipDir
+						}
+					}
+					visited = append(visited, info)
+					// a KEP directory holds a single KEP, the rest of it
+					// is supporting material
+					if _, err := fs.Stat(fsys, path.Join(name, keps.MetadataFile)); err == nil {
+						files = append(files, path.Join(name, keps.BodyFile))
+						return fs.SkipDir
+					}
+					return nil
+				}
+				if !strings.HasSuffix(d.Name(), "md") {
+					return nil
+				}
+				if !includeIgnored && ignore(d.Name()) {
+					return nil
+				}
+				files = append(files, name)
 				return nil
-			}
-			files = append(files, path)
-			return nil
-		},
-	)
+			},
+		)
+	}
+	err := walk(".")
 	return files, err
 }
 
-func parseFiles(files []string) (keps.Proposals, error) {
+// kepFiles returns the KEP files in fsys, which holds the KEPs in dirPath,
+// for names, paths of files in dirPath given on the command line. Like
+// findMarkdownFiles, a file in a KEP directory, such as its kep.yaml, stands
+// for the README.md of the KEP, and files that are not markdown, or that
+// ignore skips unless includeIgnored is set, are left out, as are files
+// outside of dirPath. Each KEP is only returned once, in the order of names.
+func kepFiles(fsys fs.FS, dirPath string, names []string, includeIgnored bool) ([]string, error) {
+	root, err := filepath.Abs(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			// hooks are often given every changed file of the repository
+			fmt.Fprintf(progress, "skipping %s, it is not under %s\n", name, dirPath)
+			continue
+		}
+		file := filepath.ToSlash(rel)
+		inKEPDir := false
+		for dir := path.Dir(file); dir != "." && !inKEPDir; dir = path.Dir(dir) {
+			if _, err := fs.Stat(fsys, path.Join(dir, keps.MetadataFile)); err == nil {
+				file, inKEPDir = path.Join(dir, keps.BodyFile), true
+			}
+		}
+		if seen[file] || !inKEPDir && (!strings.HasSuffix(file, "md") || !includeIgnored && ignore(path.Base(file))) {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// selectKEP returns the files that only names. only is either the path of a
+// KEP, relative to dirPath or including it, or the number of a numbered KEP
+// with or without leading zeros.
+func selectKEP(files []string, dirPath, only string) ([]string, error) {
+	target := filepath.ToSlash(filepath.Clean(only))
+	prefix := filepath.ToSlash(filepath.Clean(dirPath)) + "/"
+	number, err := strconv.Atoi(only)
+	isNumber := err == nil
+	for _, name := range files {
+		if name == target || prefix+name == target {
+			return []string{name}, nil
+		}
+		if n, ok := keps.PathNumber(name); isNumber && ok && n == number {
+			return []string{name}, nil
+		}
+	}
+	return nil, fmt.Errorf("no KEP under %s matches -only %q", dirPath, only)
+}
+
+// headFiles returns the first n files in path order.
+func headFiles(files []string, n int) []string {
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// parseFiles parses and validates every KEP in files, which are paths in
+// fsys. The Filename of each proposal is set to its path in fsys, and
+// dirPath is only used to name the files in messages. Unless keepBody is
+// set, the body of each KEP is dropped once it is validated, so that only
+// the metadata of the whole tree is held in memory. Files for which
+// nonFatal, if set, returns true are skipped when they fail to parse or
+// validate, rather than failing the whole run. Their errors are reported
+// the same way as the error that fails the run.
+// Files that cannot be read, such as because of their permissions, are
+// reported as they are found without stopping the run; once every other
+// file is parsed, they are returned as an unreadableFiles error along with
+// the proposals.
+// It gives up once ctx is done.
+func parseFiles(ctx context.Context, parser *keps.Parser, fsys fs.FS, dirPath string, files []string, keepBody bool, nonFatal func(name string) bool) (keps.Proposals, error) {
 	var proposals keps.Proposals
-	for _, filename := range files {
-		parser := &keps.Parser{}
-		file, err := os.Open(filename)
+	var unreadable unreadableFiles
+	for i, name := range files {
+		filename := filepath.Join(dirPath, filepath.FromSlash(name))
+		result, err := parseFile(ctx, parser, fsys, name)
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after parsing %d of %d files, while parsing %v\n", i, len(files), filename)
+		}
+		if err == nil && result.Unreadable() {
+			fmt.Fprintf(os.Stderr, "could not read %v: %v\n", filename, result.Errors[0])
+			unreadable = append(unreadable, filename)
+			continue
+		}
+		if err == nil && fix && result.Parsed() {
+			if err = fixKEP(parser, result.Proposal, filename); err == nil {
+				// validated again, since the fixes may have solved some of the problems
+				result = keps.NewParseResult(result.Proposal, name)
+			}
+		}
+		if err == nil {
+			err = validateKEP(result, filename)
+		}
+		if err == nil && stream != nil {
+			result.Proposal.Filename = name
+			err = stream(result.Proposal)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("could not open file: %v\n", err)
+			if nonFatal != nil && nonFatal(name) {
+				fmt.Fprintf(os.Stderr, "error parsing files: %q\n", err)
+				continue
+			}
+			return nil, err
 		}
-		defer file.Close()
-		kep := parser.Parse(file)
-		// if error is nil we can move on
-		if kep.Error != nil {
-			return nil, fmt.Errorf("%v has an error: %q\n", filename, kep.Error.Error())
+		fmt.Fprintf(progress, ">>>> parsed file successfully: %s\n", filename)
+		kep := result.Proposal
+		if !keepBody {
+			kep.DropBody()
 		}
-		fmt.Printf(">>>> parsed file successfully: %s\n", filename)
+		kep.Filename = name
 		proposals.AddProposal(kep)
 	}
+	if len(unreadable) > 0 {
+		return proposals, unreadable
+	}
 	return proposals, nil
 }
 
-func printJSONOutput(filePath string, proposals keps.Proposals) error {
-	fmt.Printf("Output file: %s\n", filePath)
-	file, err := os.Create(filePath)
+// parseOthers parses the KEPs of all that are not in selected, so that the
+// selected KEPs can be checked against the rest of the tree. They are only
+// parsed, not reported on, and those that do not parse are left out. Their
+// bodies are dropped. It gives up once ctx is done.
+func parseOthers(ctx context.Context, parser *keps.Parser, fsys fs.FS, all, selected []string) (keps.Proposals, error) {
+	skip := map[string]bool{}
+	for _, name := range selected {
+		skip[name] = true
+	}
+	var others keps.Proposals
+	for _, name := range all {
+		if skip[name] {
+			continue
+		}
+		result, err := parseFile(ctx, parser, fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Parsed() {
+			continue
+		}
+		kep := result.Proposal
+		kep.DropBody()
+		kep.Filename = name
+		others.AddProposal(kep)
+	}
+	return others, nil
+}
+
+// unreadableFiles are the files that parseFiles could not read.
+type unreadableFiles []string
+
+func (u unreadableFiles) Error() string {
+	return fmt.Sprintf("could not read %d files: %s", len(u), strings.Join(u, ", "))
+}
+
+// fixKEP removes the owning SIG of kep from its participating SIGs, both in
+// kep and in its file at filename, replaces typographic quotes in its
+// metadata with straight ones, and sorts its authors, reviewers,
+// approvers and participating SIGs unless -no-sort-lists is set. With
+// -normalize-lists it also rewrites the flow lists of the file as block lists.
+// The file is only written once parser.CheckFix agrees that the fixed file
+// parses to the fixed metadata.
+func fixKEP(parser *keps.Parser, kep *keps.Proposal, filename string) error {
+	contents, err := os.ReadFile(filename)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	var fixes []string
+	fixed, ok := kep.RemoveOwningSIG(contents)
+	if ok {
+		fixes = append(fixes, "removed the owning SIG from participating-sigs")
+	}
+	if fixed, ok = kep.StraightenQuotes(fixed); ok {
+		fixes = append(fixes, "straightened the quotes")
+	}
+	if normalizeLists {
+		if fixed, ok = keps.NormalizeLists(fixed); ok {
+			fixes = append(fixes, "normalized the lists")
+		}
+	}
+	if !noSortLists {
+		if fixed, ok = kep.SortLists(fixed); ok {
+			fixes = append(fixes, "sorted the lists")
+		}
+	}
+	if len(fixes) == 0 {
+		return nil
+	}
+	if err := parser.CheckFix(kep, fixed); err != nil {
+		return fmt.Errorf("could not fix %s: %v", filename, err)
+	}
+	// a symlinked KEP is fixed in place instead of replaced by a copy
+	resolved, err := filepath.EvalSymlinks(filename)
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(resolved, func(w io.Writer) error {
+		_, err := w.Write(fixed)
+		return err
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "%s: %s\n", strings.Join(fixes, ", "), filename)
+	return nil
+}
+
+// printRenames prints a git mv command for every KEP whose path has a KEP
+// number prefix that is not zero padded, see keps.Proposals.ValidateNumberPadding.
+// The files are not renamed, since that would break references to them.
+func printRenames(proposals keps.Proposals, dirPath string) {
+	for _, kep := range proposals {
+		if padded := validations.PadNumbers(kep.Filename, keps.NumberWidth); padded != kep.Filename {
+			fmt.Fprintf(progress, "git mv %s %s\n", path.Join(filepath.ToSlash(dirPath), kep.Filename), path.Join(filepath.ToSlash(dirPath), padded))
+		}
+	}
+}
 
-	total := len(proposals)
-	fmt.Printf("Total KEPs: %d\n", total)
+// validateKEP reports the warnings in the result of a parsed KEP and returns
+// its first error, if any.
+func validateKEP(result *keps.ParseResult, filename string) error {
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(warnings, "%v has a warning: %q\n", filename, warning.Message)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%v has an error: %q\n", filename, result.Errors[0].Message)
+	}
+	return nil
+}
+
+// parseFile parses and validates a single KEP, without waiting for the parse
+// to finish once ctx is done.
+func parseFile(ctx context.Context, parser *keps.Parser, fsys fs.FS, name string) (*keps.ParseResult, error) {
+	done := make(chan *keps.ParseResult, 1)
+	go func() {
+		done <- parser.ParseFileResult(fsys, name)
+	}()
+	select {
+	case result := <-done:
+		return result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
 
-	fmt.Fprintln(file, "{")
+// outputOptions control which optional fields are written for each KEP.
+type outputOptions struct {
+	// paths includes the path of the KEP relative to the KEP directory
+	paths bool
+	// noBody leaves out the markdown body of the KEP
+	noBody bool
+	// baseURL, if set, turns the paths in the markdown and search indexes
+	// into absolute links below it
+	baseURL string
+	// plainText strips the markdown syntax from the bodies in the search
+	// index
+	plainText bool
+	// tree holds every parsed KEP, including those that are not written,
+	// to resolve the references of the written KEPs against. The written
+	// KEPs are used if it is nil.
+	tree keps.Proposals
+}
+
+// printJSONOutput writes every KEP keyed by its hash, see
+// keps.Proposals.JSONBytes.
+func printJSONOutput(w io.Writer, proposals keps.Proposals, opts outputOptions) error {
+	return jsonProposals(proposals, opts).WriteJSON(w)
+}
+
+// openStream opens filePath for -format ndjson-stream. Unlike the other
+// outputs, a file is written in place as the KEPs are parsed, so that it
+// can be read while kepify runs, and is left partially written if the run
+// fails.
+func openStream(filePath string) (io.WriteCloser, error) {
+	if filePath == stdoutPath {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(filePath)
+}
+
+// nopCloser keeps stdout open once the stream is done.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// streamKEP writes proposals, the KEP that was just parsed if it was not
+// filtered out, as a line of the jsonl output. The owning SIG is checked
+// first, and the hash against written, which maps the hash of every line
+// already streamed to the file of its KEP, since a line cannot be taken
+// back once written. Each line is written with a single unbuffered write,
+// so it reaches the reader right away.
+func streamKEP(w io.Writer, proposals keps.Proposals, written map[string]string, opts outputOptions) error {
+	if errs := proposals.ValidateOwningSIGs(); len(errs) > 0 {
+		return errs[0]
+	}
+	for _, kep := range proposals {
+		if err := validations.ValidateUniqueHash(kep.Hash(), written[kep.Hash()], kep.Filename); err != nil {
+			return err
+		}
+	}
+	var line bytes.Buffer
+	if err := printJSONLinesOutput(&line, proposals, opts); err != nil {
+		return err
+	}
+	if _, err := w.Write(line.Bytes()); err != nil {
+		return err
+	}
+	for _, kep := range proposals {
+		written[kep.Hash()] = kep.Filename
+	}
+	return nil
+}
+
+// printJSONLinesOutput writes each KEP as a json object on a line of its
+// own, with the hash that keys it in the json output as its "hash" field.
+func printJSONLinesOutput(w io.Writer, proposals keps.Proposals, opts outputOptions) error {
+	for _, kep := range jsonProposals(proposals, opts) {
+		contents, err := kep.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "{\"hash\":%q,%s\n", kep.Hash(), contents[1:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printMarkdownIndex writes a markdown table listing every KEP.
+func printMarkdownIndex(w io.Writer, proposals keps.Proposals, opts outputOptions) error {
+	columns := []string{"title", "owning-sig", "status", "last-updated"}
+	if opts.paths || opts.baseURL != "" {
+		columns = append(columns, "path")
+	}
+	if opts.baseURL != "" {
+		proposals = linkProposals(proposals, opts.baseURL)
+	}
+	fmt.Fprintf(w, "<!-- generated by kepify %s -->\n\n", keps.BuildInfo())
+	return proposals.ToMarkdownTable(w, columns, "owning-sig")
+}
+
+// printSearchIndex writes a search record for every KEP, see
+// keps.Proposals.WriteSearchIndex. The files that kepify skips by default
+// are left out even with -include-ignored.
+func printSearchIndex(w io.Writer, proposals keps.Proposals, opts outputOptions) error {
+	proposals = proposals.Filter(func(kep *keps.Proposal) bool {
+		return !ignore(path.Base(kep.Filename))
+	})
+	if opts.baseURL != "" {
+		proposals = linkProposals(proposals, opts.baseURL)
+	}
+	return proposals.WriteSearchIndex(w, opts.plainText)
+}
+
+// printHTMLReport writes a standalone HTML page listing every KEP, see
+// keps.Proposals.ToHTML. The titles link to the path of each KEP, or to its
+// URL below -base-url.
+func printHTMLReport(w io.Writer, proposals keps.Proposals, opts outputOptions) error {
+	if opts.baseURL != "" {
+		proposals = linkProposals(proposals, opts.baseURL)
+	}
+	return proposals.ToHTML(w)
+}
+
+// printTOC writes a markdown table of contents of every KEP, grouped by
+// owning SIG and then status, see keps.Proposals.ToTOC.
+func printTOC(w io.Writer, proposals keps.Proposals, opts outputOptions) error {
+	if opts.baseURL != "" {
+		proposals = linkProposals(proposals, opts.baseURL)
+	}
+	fmt.Fprintf(w, "<!-- generated by kepify %s -->\n\n", keps.BuildInfo())
+	return proposals.ToTOC(w)
+}
+
+// printYAMLOutput writes the metadata of every KEP as a YAML list. The
+// markdown body is never included.
+func printYAMLOutput(w io.Writer, proposals keps.Proposals) error {
+	return proposals.ToYAML(w)
+}
+
+// linkProposals returns copies of proposals whose Filename is the absolute
+// URL of the KEP below baseURL.
+func linkProposals(proposals keps.Proposals, baseURL string) keps.Proposals {
+	linked := make(keps.Proposals, len(proposals))
 	for i, kep := range proposals {
-		fmt.Fprintf(file, "\t\"%s\": {\n", hash(kep.OwningSIG+":"+kep.Title))
-		fmt.Fprintf(file, "\t\t\"%s\": \"%s\",\n", "title", kep.Title)
-		fmt.Fprintf(file, "\t\t\"%s\": \"%s\",\n", "owning-sig", kep.OwningSIG)
-		fmt.Fprintf(file, "\t\t\"%s\": %s,\n", "participating-sigs", marshal(kep.ParticipatingSIGs))
-		fmt.Fprintf(file, "\t\t\"%s\": %s,\n", "reviewers", marshal(kep.Reviewers))
-		fmt.Fprintf(file, "\t\t\"%s\": %s,\n", "authors", marshal(kep.Authors))
-		fmt.Fprintf(file, "\t\t\"%s\": \"%s\",\n", "editor", kep.Editor)
-		fmt.Fprintf(file, "\t\t\"%s\": \"%s\",\n", "creation-date", kep.CreationDate)
-		fmt.Fprintf(file, "\t\t\"%s\": \"%s\",\n", "last-updated", kep.LastUpdated)
-		fmt.Fprintf(file, "\t\t\"%s\": \"%s\",\n", "status", kep.Status)
-		fmt.Fprintf(file, "\t\t\"%s\": %s,\n", "see-also", marshal(kep.SeeAlso))
-		fmt.Fprintf(file, "\t\t\"%s\": %s,\n", "replaces", marshal(kep.Replaces))
-		fmt.Fprintf(file, "\t\t\"%s\": %s,\n", "superseded-by", marshal(kep.SupersededBy))
-		contents, _ := json.Marshal(kep.Contents)
-		fmt.Fprintf(file, "\t\t\"%s\": %s\n", "markdown", contents)
-		if i < total-1 {
-			fmt.Fprintln(file, "\t},")
-		} else {
-			fmt.Fprintln(file, "\t}")
-		}
-	}
-	fmt.Fprintln(file, "}")
+		copied := *kep
+		copied.Filename = kepURL(baseURL, kep.Filename)
+		linked[i] = &copied
+	}
+	return linked
+}
+
+// kepURL joins baseURL and the slash separated path of a KEP, with exactly
+// one slash between them.
+func kepURL(baseURL, kepPath string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(kepPath, "/")
+}
+
+// jsonProposals returns copies of proposals that only hold the fields opts
+// asks for in the json based outputs.
+func jsonProposals(proposals keps.Proposals, opts outputOptions) keps.Proposals {
+	selected := make(keps.Proposals, len(proposals))
+	for i, kep := range proposals {
+		copied := *kep
+		if !opts.paths {
+			copied.Filename = ""
+		}
+		if opts.noBody {
+			copied.DropBody()
+		}
+		selected[i] = &copied
+	}
+	return selected
+}
+
+func printStats(proposals keps.Proposals) {
+	fmt.Printf("Total KEPs: %d\n", proposals.Count())
+	fmt.Println("By status:")
+	printCounts(proposals.CountByStatus())
+	fmt.Println("By SIG:")
+	printCounts(proposals.CountBySIG())
+}
+
+func printCounts(counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("\t%s: %d\n", key, counts[key])
+	}
+}
+
+// groupSelectors are the fields that -group-by accepts, along with how to
+// read them from a KEP.
+var groupSelectors = []struct {
+	name  string
+	field func(*keps.Proposal) string
+}{
+	{"sig", func(kep *keps.Proposal) string { return kep.OwningSIG }},
+	{"status", func(kep *keps.Proposal) string { return kep.Status }},
+	{"stage", func(kep *keps.Proposal) string { return kep.Stage }},
+	{"milestone", func(kep *keps.Proposal) string { return kep.LatestMilestone }},
+}
+
+func groupByNames() []string {
+	names := make([]string, len(groupSelectors))
+	for i, selector := range groupSelectors {
+		names[i] = selector.name
+	}
+	return names
+}
+
+// groupSelector returns how to read the -group-by field name from a KEP, or
+// nil if name is not one of groupSelectors.
+func groupSelector(name string) func(*keps.Proposal) string {
+	for _, selector := range groupSelectors {
+		if selector.name == name {
+			return selector.field
+		}
+	}
 	return nil
 }
 
+// printGroupedStats writes an aligned table of the number of KEPs for each
+// value of the -group-by field groupBy, followed by the total. Values are
+// sorted by name, milestones by version, and KEPs without a value are
+// counted as "none".
+func printGroupedStats(w io.Writer, proposals keps.Proposals, groupBy string) error {
+	groups := proposals.GroupBy(groupSelector(groupBy))
+	values := make([]string, 0, len(groups))
+	for value := range groups {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		a, errA := keps.ParseKubeVersion(values[i])
+		b, errB := keps.ParseKubeVersion(values[j])
+		if errA == nil && errB == nil {
+			return a.Less(b)
+		}
+		return values[i] < values[j]
+	})
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "%s\tKEPs\n", groupBy)
+	for _, value := range values {
+		label := value
+		if label == "" {
+			label = "none"
+		}
+		fmt.Fprintf(table, "%s\t%d\n", label, len(groups[value]))
+	}
+	fmt.Fprintf(table, "total\t%d\n", len(proposals))
+	return table.Flush()
+}
+
+// printSIGSummary writes a CSV table of the number of KEPs with each status
+// for every SIG. SIGs are sorted by name and every status is a column, in
+// lifecycle order, even when no KEP has it.
+func printSIGSummary(w io.Writer, proposals keps.Proposals) error {
+	statuses := validations.Statuses()
+	groups := proposals.GroupBySIG()
+	sigs := make([]string, 0, len(groups))
+	for sig := range groups {
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+
+	out := csv.NewWriter(w)
+	out.Write(append([]string{"sig"}, statuses...))
+	for _, sig := range sigs {
+		counts := groups[sig].CountByStatus()
+		row := []string{sig}
+		for _, status := range statuses {
+			row = append(row, strconv.Itoa(counts[status]))
+		}
+		out.Write(row)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// printKeyCounts prints how many KEPs use each metadata key, most used
+// first.
+func printKeyCounts(proposals keps.Proposals) {
+	counts := proposals.CountByKey()
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	fmt.Printf("Total KEPs: %d\n", proposals.Count())
+	for _, key := range keys {
+		fmt.Printf("\t%s: %d\n", key, counts[key])
+	}
+}
+
 func marshal(array []string) string {
 	contents, _ := json.Marshal(array)
 	return string(contents)
 }
 
-func hash(s string) string {
-	return fmt.Sprintf("%x", md5.Sum([]byte(s)))
-}
-
-/// ignore certain files in the keps/ subdirectory
+// ignore certain files in the keps/ subdirectory
 func ignore(name string) bool {
-	if !strings.HasSuffix(name, "md") {
-		return true
-	}
-	if name == "0023-documentation-for-images.md" ||
-		name == "0004-cloud-provider-template.md" ||
-		name == "0001a-meta-kep-implementation.md" ||
-		name == "0001-kubernetes-enhancement-proposal-process.md" ||
-		name == "YYYYMMDD-kep-template.md" ||
-		name == "README.md" ||
-		name == "kep-faq.md" {
-		return true
-	}
-	return false
+	return keps.Ignored(name)
 }
@@ -0,0 +1,1167 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestFindMarkdownFilesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "keps")
+	shared := filepath.Join(root, "shared")
+	for _, d := range []string{filepath.Join(dir, "sig-a"), shared} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{filepath.Join(dir, "sig-a", "a.md"), filepath.Join(shared, "b.md")} {
+		if err := os.WriteFile(f, []byte("---\n---\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a symlinked SIG directory, and a link back up the tree to form a cycle
+	if err := os.Symlink(shared, filepath.Join(dir, "sig-b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(shared, "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name           string
+		followSymlinks bool
+		expected       []string
+	}{
+		{
+			name:     "symlinks are not followed by default",
+			expected: []string{"sig-a/a.md"},
+		},
+		{
+			name:           "symlinks are followed without cycling",
+			followSymlinks: true,
+			expected:       []string{"sig-a/a.md", "sig-b/b.md"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := findMarkdownFiles(os.DirFS(dir), tc.followSymlinks, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(files)
+			if len(files) != len(tc.expected) {
+				t.Fatalf("expected %v but got %v", tc.expected, files)
+			}
+			for i := range files {
+				if files[i] != tc.expected[i] {
+					t.Fatalf("expected %v but got %v", tc.expected, files)
+				}
+			}
+		})
+	}
+}
+
+func TestSetFlagsFromEnv(t *testing.T) {
+	env := map[string]string{
+		"KEPIFY_DIR":       "from-env",
+		"KEPIFY_MIN_WORDS": "10",
+		"KEPIFY_SIG":       "sig-ignored",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	fs := flag.NewFlagSet("kepify", flag.ContinueOnError)
+	dir := fs.String("dir", "keps", "")
+	output := fs.String("output", "keps.json", "")
+	minWords := fs.Int("min-words", 150, "")
+	var sigs stringList
+	fs.Var(&sigs, "sig", "")
+	if err := setFlagsFromEnv(fs, lookup); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Parse([]string{"-min-words", "20"}); err != nil {
+		t.Fatal(err)
+	}
+	if *dir != "from-env" {
+		t.Errorf("expected -dir from the environment but got %q", *dir)
+	}
+	if *output != "keps.json" {
+		t.Errorf("expected the default -output but got %q", *output)
+	}
+	if *minWords != 20 {
+		t.Errorf("expected -min-words from the command line but got %d", *minWords)
+	}
+	if len(sigs) != 0 {
+		t.Errorf("expected repeatable flags to ignore the environment but got %v", sigs)
+	}
+
+	env["KEPIFY_MIN_WORDS"] = "many"
+	if err := setFlagsFromEnv(fs, lookup); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
+
+func TestInferFormat(t *testing.T) {
+	testcases := []struct {
+		filePath string
+		format   string
+		ok       bool
+	}{
+		{"keps.json", "json", true},
+		{"out/keps.jsonl", "jsonl", true},
+		{"keps.yaml", "yaml", true},
+		{"keps.yml", "yaml", true},
+		{"keps.db", "sqlite", true},
+		{"KEPS.DB", "sqlite", true},
+		{"index.md", "markdown-index", true},
+		{"keps.dot", "dot", true},
+		{"keps.gv", "dot", true},
+		{"keps.html", "html", true},
+		{"keps.txt", "json", false},
+		{"keps", "json", false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.filePath, func(t *testing.T) {
+			format, ok := inferFormat(tc.filePath)
+			if format != tc.format || ok != tc.ok {
+				t.Errorf("expected (%q, %v) but got (%q, %v)", tc.format, tc.ok, format, ok)
+			}
+		})
+	}
+}
+
+// testKEPs is a snapshot of KEPs compiled into the test binary, the way a
+// self-contained tool would ship them.
+//
+//go:embed testdata/keps
+var testKEPs embed.FS
+
+func TestParseEmbeddedKEPs(t *testing.T) {
+	defer func(w io.Writer, minWords int) {
+		progress = w
+		keps.MinWordCount = minWords
+	}(progress, keps.MinWordCount)
+	progress = io.Discard
+	keps.MinWordCount = 0
+
+	fsys, err := fs.Sub(testKEPs, "testdata/keps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := findMarkdownFiles(fsys, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// README.md, the template and OWNERS are ignored, unless the README.md
+	// has a kep.yaml next to it
+	expected := []string{"sig-node/1234-split-metadata/README.md", "sig-node/20200101-embedded-kep.md"}
+	if len(files) != len(expected) || files[0] != expected[0] || files[1] != expected[1] {
+		t.Fatalf("expected %v but got %v", expected, files)
+	}
+	proposals, err := parseFiles(context.Background(), &keps.Parser{}, fsys, "testdata/keps", files, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proposals.Count() != 2 || proposals[0].Title != "Split Metadata KEP" || proposals[1].Title != "Embedded KEP" || proposals[1].Filename != files[1] {
+		t.Fatalf("unexpected proposals %+v", proposals)
+	}
+}
+
+func TestParseFilesUnreadable(t *testing.T) {
+	defer func(w io.Writer, minWords int) {
+		progress = w
+		keps.MinWordCount = minWords
+	}(progress, keps.MinWordCount)
+	progress = io.Discard
+	keps.MinWordCount = 0
+
+	fsys, err := fs.Sub(testKEPs, "testdata/keps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"sig-node/1234-split-metadata/README.md", "sig-node/missing.md", "sig-node/20200101-embedded-kep.md"}
+	proposals, err := parseFiles(context.Background(), &keps.Parser{}, fsys, "keps", files, false, nil)
+	unreadable, ok := err.(unreadableFiles)
+	if !ok || len(unreadable) != 1 || unreadable[0] != filepath.Join("keps", "sig-node", "missing.md") {
+		t.Fatalf("expected only the missing file to be unreadable but got %v", err)
+	}
+	if proposals.Count() != 2 {
+		t.Fatalf("expected the other two KEPs to be parsed but got %+v", proposals)
+	}
+}
+
+func TestParseFilesStream(t *testing.T) {
+	defer func(w io.Writer, minWords int) {
+		progress = w
+		keps.MinWordCount = minWords
+		stream = nil
+	}(progress, keps.MinWordCount)
+	progress = io.Discard
+	keps.MinWordCount = 0
+
+	fsys, err := fs.Sub(testKEPs, "testdata/keps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	written := map[string]string{}
+	stream = func(kep *keps.Proposal) error {
+		if kep.Contents == "" {
+			t.Errorf("expected %s to be streamed with its body", kep.Filename)
+		}
+		return streamKEP(&out, keps.Proposals{kep}, written, outputOptions{paths: true})
+	}
+	files := []string{"sig-node/20200101-embedded-kep.md", "sig-node/1234-split-metadata/README.md"}
+	proposals, err := parseFiles(context.Background(), &keps.Parser{}, fsys, "keps", files, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(files) {
+		t.Fatalf("expected a line for each KEP but got:\n%s", out.String())
+	}
+	for i, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %d is not json: %v", i+1, err)
+		}
+		if record["hash"] != proposals[i].Hash() || record["path"] != files[i] {
+			t.Errorf("expected line %d to be %s but got %s", i+1, files[i], line)
+		}
+	}
+	if proposals[0].Contents != "" {
+		t.Error("expected the body to be dropped once streamed")
+	}
+}
+
+func TestNoFail(t *testing.T) {
+	if os.Getenv("GO_WANT_KEPIFY_MAIN") == "1" {
+		for i, arg := range os.Args {
+			if arg == "--" {
+				os.Args = append([]string{"kepify"}, os.Args[i+1:]...)
+				break
+			}
+		}
+		main()
+		os.Exit(0)
+	}
+
+	dir := t.TempDir()
+	valid, err := testKEPs.ReadFile("testdata/keps/sig-node/20200101-embedded-kep.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := strings.Replace(string(valid), "status: provisional", "status: unknown", 1)
+	if err := os.Mkdir(filepath.Join(dir, "sig-node"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{"20200101-valid.md": string(valid), "20200102-invalid.md": invalid} {
+		if err := os.WriteFile(filepath.Join(dir, "sig-node", name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "keps.json")
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestNoFail$", "--", "-dir", dir, "-output", output, "-relative-paths", "-no-fail")
+	cmd.Env = append(os.Environ(), "GO_WANT_KEPIFY_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("expected -no-fail to exit 0 but got %v:\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "20200102-invalid.md") {
+		t.Errorf("expected the invalid KEP to be reported but got:\n%s", out)
+	}
+	contents, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]struct{ Path string }
+	if err := json.Unmarshal(contents, &decoded); err != nil {
+		t.Fatalf("expected valid json but got %v:\n%s", err, contents)
+	}
+	var paths []string
+	for key, kep := range decoded {
+		if key != "kepify" {
+			paths = append(paths, kep.Path)
+		}
+	}
+	if len(paths) != 1 || paths[0] != "sig-node/20200101-valid.md" {
+		t.Errorf("expected only the valid KEP in the output but got %v", paths)
+	}
+}
+
+func TestReportsKeepStdout(t *testing.T) {
+	for _, args := range [][]string{{"-stats"}, {"-print-fields"}, {"-group-by", "sig"}} {
+		t.Run(args[0], func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], append([]string{"-test.run=^TestNoFail$", "--", "-dir", "testdata/keps"}, args...)...)
+			cmd.Env = append(os.Environ(), "GO_WANT_KEPIFY_MAIN=1")
+			var stdout strings.Builder
+			cmd.Stdout = &stdout
+			if err := cmd.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Contains(stdout.String(), "parsed file successfully") || stdout.Len() == 0 {
+				t.Errorf("expected only the report on stdout but got:\n%s", stdout.String())
+			}
+		})
+	}
+}
+
+// blockingFS blocks opening name until release is closed.
+type blockingFS struct {
+	fs.FS
+	name    string
+	release chan struct{}
+}
+
+func (b blockingFS) Open(name string) (fs.File, error) {
+	if name == b.name {
+		<-b.release
+	}
+	return b.FS.Open(name)
+}
+
+func TestParseFilesTimeout(t *testing.T) {
+	defer func(w io.Writer, minWords int) {
+		progress = w
+		keps.MinWordCount = minWords
+	}(progress, keps.MinWordCount)
+	progress = io.Discard
+	keps.MinWordCount = 0
+
+	sub, err := fs.Sub(testKEPs, "testdata/keps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := blockingFS{FS: sub, name: "sig-node/20200101-embedded-kep.md", release: make(chan struct{})}
+	defer close(fsys.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	files := []string{"sig-node/1234-split-metadata/README.md", fsys.name}
+	proposals, err := parseFiles(ctx, &keps.Parser{}, fsys, "keps", files, false, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out after parsing 1 of 2 files") {
+		t.Fatalf("expected the run to time out on the blocked file but got %v", err)
+	}
+	if proposals != nil {
+		t.Errorf("expected no proposals after a timeout but got %+v", proposals)
+	}
+}
+
+func TestParseOthers(t *testing.T) {
+	defer func(minWords int) { keps.MinWordCount = minWords }(keps.MinWordCount)
+	keps.MinWordCount = 0
+
+	fsys := fstest.MapFS{
+		"sig-node/0001-selected.md": {Data: []byte("---\ntitle: selected\nowning-sig: sig-node\nstatus: provisional\n---\n# Body\n")},
+		"sig-node/0002-other.md":    {Data: []byte("---\ntitle: other\nowning-sig: sig-node\nstatus: provisional\n---\n# Body\n")},
+		"sig-node/0003-invalid.md":  {Data: []byte("---\ntitle: [invalid\n---\n")},
+	}
+	all, err := findMarkdownFiles(fsys, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	others, err := parseOthers(context.Background(), &keps.Parser{}, fsys, all, []string{"sig-node/0001-selected.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(others) != 1 || others[0].Filename != "sig-node/0002-other.md" || others[0].Contents != "" {
+		t.Fatalf("expected only the other KEP that parses, without its body, but got %+v", others)
+	}
+}
+
+func TestStreamKEPOwningSIG(t *testing.T) {
+	var out strings.Builder
+	if err := streamKEP(&out, keps.Proposals{{Title: "Dry run"}}, map[string]string{}, outputOptions{}); err == nil {
+		t.Fatal("expected a KEP without owning-sig to be rejected")
+	}
+	if out.Len() != 0 {
+		t.Fatalf("did not expect a line but got %q", out.String())
+	}
+}
+
+func TestStreamKEPUniqueHash(t *testing.T) {
+	var out strings.Builder
+	written := map[string]string{}
+	first := &keps.Proposal{Title: "Dry run", OwningSIG: "sig-api-machinery", Filename: "sig-api-machinery/0015-dry-run.md"}
+	second := &keps.Proposal{Title: "Dry run", OwningSIG: "sig-api-machinery", Filename: "sig-api-machinery/0016-dry-run.md"}
+	if err := streamKEP(&out, keps.Proposals{first}, written, outputOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	lines := out.String()
+	err := streamKEP(&out, keps.Proposals{second}, written, outputOptions{})
+	if err == nil || !strings.Contains(err.Error(), first.Filename) || !strings.Contains(err.Error(), second.Filename) {
+		t.Fatalf("expected an error naming both KEPs but got %v", err)
+	}
+	if out.String() != lines {
+		t.Fatalf("did not expect the second KEP to be written but got %q", out.String())
+	}
+}
+
+func TestIncludeIgnored(t *testing.T) {
+	defer func(w io.Writer, minWords int) {
+		progress = w
+		keps.MinWordCount = minWords
+	}(progress, keps.MinWordCount)
+	progress = io.Discard
+	keps.MinWordCount = 0
+
+	fsys, err := fs.Sub(testKEPs, "testdata/keps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := findMarkdownFiles(fsys, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"README.md", "YYYYMMDD-kep-template.md", "sig-node/1234-split-metadata/README.md", "sig-node/20200101-embedded-kep.md"}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v but got %v", expected, files)
+	}
+
+	// the README has no frontmatter and the template has no owning-sig
+	if _, err := parseFiles(context.Background(), &keps.Parser{}, fsys, "keps", files, false, nil); err == nil {
+		t.Fatal("expected the README to fail the run")
+	}
+	nonFatal := func(name string) bool { return ignore(path.Base(name)) }
+	proposals, err := parseFiles(context.Background(), &keps.Parser{}, fsys, "keps", files, false, nonFatal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proposals.Count() != 2 || proposals[0].Filename != expected[2] || proposals[1].Filename != expected[3] {
+		t.Fatalf("expected only the two KEPs but got %+v", proposals)
+	}
+}
+
+func TestSelectKEP(t *testing.T) {
+	files := []string{"sig-api-machinery/0015-dry-run.md", "sig-apps/0026-ttl-after-finish.md", "sig-node/20190129-hugepages.md", "sig-node/1234-split-metadata/README.md"}
+	testcases := []struct {
+		only     string
+		expected string
+	}{
+		{"sig-apps/0026-ttl-after-finish.md", "sig-apps/0026-ttl-after-finish.md"},
+		{"keps/sig-node/20190129-hugepages.md", "sig-node/20190129-hugepages.md"},
+		{"15", "sig-api-machinery/0015-dry-run.md"},
+		{"0026", "sig-apps/0026-ttl-after-finish.md"},
+		{"1234", "sig-node/1234-split-metadata/README.md"},
+		{"20190129", ""},
+		{"sig-node/missing.md", ""},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.only, func(t *testing.T) {
+			selected, err := selectKEP(files, "keps", tc.only)
+			if tc.expected == "" {
+				if err == nil {
+					t.Fatalf("expected an error but got %v", selected)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(selected) != 1 || selected[0] != tc.expected {
+				t.Fatalf("expected %q but got %v", tc.expected, selected)
+			}
+		})
+	}
+}
+
+func TestKEPFiles(t *testing.T) {
+	defer func(w io.Writer) { progress = w }(progress)
+	progress = io.Discard
+
+	fsys, err := fs.Sub(testKEPs, "testdata/keps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{
+		"testdata/keps/sig-node/20200101-embedded-kep.md",
+		"testdata/keps/sig-node/1234-split-metadata/kep.yaml",
+		"testdata/keps/sig-node/1234-split-metadata/design.md",
+		filepath.Join("testdata", "keps", "sig-node", "OWNERS"),
+		"testdata/keps/YYYYMMDD-kep-template.md",
+		"./testdata/keps/sig-node/20200101-embedded-kep.md",
+	}
+	files, err := kepFiles(fsys, "testdata/keps", names, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"sig-node/20200101-embedded-kep.md", "sig-node/1234-split-metadata/README.md"}
+	if strings.Join(files, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected %v but got %v", expected, files)
+	}
+	if files, err = kepFiles(fsys, "testdata/keps", names, true); err != nil || len(files) != 3 || files[2] != "YYYYMMDD-kep-template.md" {
+		t.Errorf("expected the template to be included but got %v, %v", files, err)
+	}
+	if files, err := kepFiles(fsys, "testdata/keps", []string{"main.go"}, false); err != nil || len(files) != 0 {
+		t.Errorf("expected a file that is not under -dir to be skipped but got %v, %v", files, err)
+	}
+}
+
+func TestHeadFiles(t *testing.T) {
+	files := []string{"sig-node/b.md", "sig-apps/a.md", "sig-node/a.md"}
+	head := headFiles(files, 2)
+	if len(head) != 2 || head[0] != "sig-apps/a.md" || head[1] != "sig-node/a.md" {
+		t.Errorf("expected the first two files in path order but got %v", head)
+	}
+	if all := headFiles(files, 10); len(all) != len(files) {
+		t.Errorf("expected every file when there are fewer than the limit but got %v", all)
+	}
+	if files[0] != "sig-node/b.md" {
+		t.Errorf("expected the files to be left unchanged but got %v", files)
+	}
+}
+
+func TestKEPURL(t *testing.T) {
+	const expected = "https://github.com/kubernetes/enhancements/blob/master/keps/sig-apps/0026-ttl-after-finish.md"
+	for _, baseURL := range []string{
+		"https://github.com/kubernetes/enhancements/blob/master/keps",
+		"https://github.com/kubernetes/enhancements/blob/master/keps/",
+		"https://github.com/kubernetes/enhancements/blob/master/keps//",
+	} {
+		if url := kepURL(baseURL, "sig-apps/0026-ttl-after-finish.md"); url != expected {
+			t.Errorf("expected %q for %q but got %q", expected, baseURL, url)
+		}
+	}
+}
+
+// BenchmarkParseFilesRetained reports how much memory the parsed proposals
+// of a tree with large bodies hold on to, with and without -no-body.
+func BenchmarkParseFilesRetained(b *testing.B) {
+	defer func(w io.Writer) { progress = w }(progress)
+	progress = io.Discard
+
+	const kepCount = 100
+	body := strings.Repeat("A paragraph of a very long KEP body.\n", 10000)
+	fsys := fstest.MapFS{}
+	var files []string
+	for i := 0; i < kepCount; i++ {
+		name := fmt.Sprintf("sig-node/%04d-large.md", i)
+		fsys[name] = &fstest.MapFile{Data: []byte(fmt.Sprintf("---\ntitle: KEP %d\nauthors:\n  - \"@jpbetz\"\nowning-sig: sig-node\nstatus: provisional\n---\n%s", i, body))}
+		files = append(files, name)
+	}
+
+	for _, keepBody := range []bool{true, false} {
+		b.Run(fmt.Sprintf("keepBody=%v", keepBody), func(b *testing.B) {
+			b.ReportAllocs()
+			var retained uint64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				proposals, err := parseFiles(context.Background(), &keps.Parser{}, fsys, "keps", files, keepBody, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				if after.HeapAlloc > before.HeapAlloc {
+					retained += after.HeapAlloc - before.HeapAlloc
+				}
+				runtime.KeepAlive(proposals)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+		})
+	}
+}
+
+// BenchmarkFindAndParse walks and parses a synthetic tree of KEPs spread
+// over several SIG directories.
+func BenchmarkFindAndParse(b *testing.B) {
+	defer func(p, w io.Writer) { progress, warnings = p, w }(progress, warnings)
+	progress, warnings = io.Discard, io.Discard
+
+	body := strings.Repeat("## Section\n\nSome text about the proposal.\n\n", 50)
+	fsys := fstest.MapFS{}
+	for _, sig := range []string{"sig-apps", "sig-node", "sig-storage", "sig-network"} {
+		for i := 0; i < 250; i++ {
+			fsys[fmt.Sprintf("%s/%04d-kep.md", sig, i)] = &fstest.MapFile{Data: []byte(fmt.Sprintf("---\ntitle: KEP %d\nauthors:\n  - \"@jpbetz\"\nowning-sig: %s\nstatus: provisional\n---\n# KEP\n\n%s", i, sig, body))}
+		}
+		fsys[sig+"/README.md"] = &fstest.MapFile{Data: []byte("# " + sig + "\n")}
+	}
+
+	b.Run("walk", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := findMarkdownFiles(fsys, false, false); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("walk and parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			files, err := findMarkdownFiles(fsys, false, false)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := parseFiles(context.Background(), &keps.Parser{}, fsys, "keps", files, false, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestBackupFile(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "keps.json")
+	if err := backupFile(output); err != nil {
+		t.Fatalf("expected a missing output to be skipped but got %v", err)
+	}
+	if _, err := os.Stat(output + ".bak"); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup of a missing output but got %v", err)
+	}
+
+	for _, contents := range []string{"first", "second"} {
+		if err := os.WriteFile(output, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := backupFile(output); err != nil {
+			t.Fatal(err)
+		}
+		backup, err := os.ReadFile(output + ".bak")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(backup) != contents {
+			t.Fatalf("expected the backup to hold %q but got %q", contents, backup)
+		}
+		kept, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("expected the output to be kept until it is replaced but got %v", err)
+		}
+		if string(kept) != contents {
+			t.Fatalf("expected the output to still hold %q but got %q", contents, kept)
+		}
+	}
+}
+
+func TestPrintJSONOutputVersion(t *testing.T) {
+	defer func(version string) { keps.Version = version }(keps.Version)
+	keps.Version = "v0.1.0"
+
+	testcases := []struct {
+		name      string
+		proposals keps.Proposals
+	}{
+		{"no KEPs", nil},
+		{"one KEP", keps.Proposals{{Title: "A KEP", OwningSIG: "sig-node"}}},
+	}
+	for _, tc := range testcases {
+		output := filepath.Join(t.TempDir(), "keps.json")
+		err := writeOutput(output, func(w io.Writer) error {
+			return printJSONOutput(w, tc.proposals, outputOptions{})
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		contents, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded map[string]json.RawMessage
+		if err := json.Unmarshal(contents, &decoded); err != nil {
+			t.Fatalf("%s: expected valid json but got %v:\n%s", tc.name, err, contents)
+		}
+		if len(decoded) != len(tc.proposals)+1 {
+			t.Errorf("%s: expected %d entries but got %d", tc.name, len(tc.proposals)+1, len(decoded))
+		}
+		var info struct{ Version string }
+		if err := json.Unmarshal(decoded["kepify"], &info); err != nil || info.Version != "v0.1.0" {
+			t.Errorf("%s: expected the kepify version v0.1.0 but got %q (%v)", tc.name, info.Version, err)
+		}
+	}
+}
+
+func TestPrintJSONOutputPaths(t *testing.T) {
+	defer func(w io.Writer, minWords int) {
+		progress = w
+		keps.MinWordCount = minWords
+	}(progress, keps.MinWordCount)
+	progress = io.Discard
+	keps.MinWordCount = 0
+
+	fsys, err := fs.Sub(testKEPs, "testdata/keps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"sig-node/1234-split-metadata/README.md", "sig-node/20200101-embedded-kep.md"}
+	proposals, err := parseFiles(context.Background(), &keps.Parser{}, fsys, "keps", files, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, relativePaths := range []bool{false, true} {
+		var out strings.Builder
+		if err := printJSONOutput(&out, proposals, outputOptions{paths: relativePaths}); err != nil {
+			t.Fatal(err)
+		}
+		var decoded map[string]map[string]interface{}
+		if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+			t.Fatalf("expected valid json but got %v:\n%s", err, out.String())
+		}
+		for i, kep := range proposals {
+			record, ok := decoded[kep.Hash()]
+			if !ok {
+				t.Fatalf("expected %s in the output but got:\n%s", files[i], out.String())
+			}
+			path, ok := record["path"]
+			switch {
+			case relativePaths && path != files[i]:
+				t.Errorf("-relative-paths: expected the path %s but got %v", files[i], path)
+			case !relativePaths && ok:
+				t.Errorf("expected no path without -relative-paths but got %v", path)
+			}
+		}
+	}
+	if proposals[0].Filename != files[0] {
+		t.Errorf("expected the parsed KEP to keep its path but got %q", proposals[0].Filename)
+	}
+}
+
+func TestPrintJSONLinesOutput(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "A KEP", OwningSIG: "sig-node", Filename: "sig-node/20200101-a-kep.md"},
+		{Title: "Another KEP", OwningSIG: "sig-cli", Filename: "sig-cli/20200102-another-kep.md"},
+	}
+	var out strings.Builder
+	if err := printJSONLinesOutput(&out, proposals, outputOptions{paths: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(out.String(), "[") {
+		t.Fatalf("expected no array around the KEPs but got:\n%s", out.String())
+	}
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) != len(proposals)+1 || lines[len(proposals)] != "" {
+		t.Fatalf("expected a line for each KEP ending in a newline but got:\n%s", out.String())
+	}
+	for i, kep := range proposals {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[i]), &record); err != nil {
+			t.Fatalf("line %d is not a json object: %v", i+1, err)
+		}
+		if record["hash"] != kep.Hash() || record["title"] != kep.Title || record["path"] != kep.Filename {
+			t.Errorf("expected line %d to be %s but got %s", i+1, kep.Filename, lines[i])
+		}
+	}
+}
+
+func TestPrintSQLiteOutput(t *testing.T) {
+	kep := &keps.Proposal{
+		Title:     "A KEP",
+		OwningSIG: "sig-node",
+		Approvers: []string{"@a", "@b"},
+		Filename:  "sig-node/1234-a-kep/README.md",
+		Contents:  "# A KEP",
+	}
+	testcases := []struct {
+		name     string
+		opts     outputOptions
+		path     sql.NullString
+		markdown sql.NullString
+	}{
+		{"defaults", outputOptions{}, sql.NullString{}, sql.NullString{String: "# A KEP", Valid: true}},
+		{"relative paths", outputOptions{paths: true}, sql.NullString{String: kep.Filename, Valid: true}, sql.NullString{String: "# A KEP", Valid: true}},
+		{"no body", outputOptions{noBody: true}, sql.NullString{}, sql.NullString{}},
+	}
+	for _, tc := range testcases {
+		output := filepath.Join(t.TempDir(), "keps.db")
+		if err := printSQLiteOutput(output, keps.Proposals{kep}, tc.opts); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		db, err := sql.Open("sqlite", output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := db.Query("SELECT hash, title, owning_sig, approvers, path, markdown FROM keps")
+		if err != nil {
+			db.Close()
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		count := 0
+		for rows.Next() {
+			count++
+			var hash, title, sig, approvers string
+			var path, markdown sql.NullString
+			if err := rows.Scan(&hash, &title, &sig, &approvers, &path, &markdown); err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if hash != kep.Hash() || title != kep.Title || sig != kep.OwningSIG {
+				t.Errorf("%s: expected %s %q %s but got %s %q %s", tc.name, kep.Hash(), kep.Title, kep.OwningSIG, hash, title, sig)
+			}
+			if approvers != `["@a","@b"]` {
+				t.Errorf("%s: expected the approvers as json but got %s", tc.name, approvers)
+			}
+			if path != tc.path {
+				t.Errorf("%s: expected the path %v but got %v", tc.name, tc.path, path)
+			}
+			if markdown != tc.markdown {
+				t.Errorf("%s: expected the markdown %v but got %v", tc.name, tc.markdown, markdown)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+		rows.Close()
+		db.Close()
+		if count != 1 {
+			t.Errorf("%s: expected one row but got %d", tc.name, count)
+		}
+	}
+}
+
+func TestPrintSIGSummary(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "a", OwningSIG: "sig-storage", Status: "implementable"},
+		{Title: "b", OwningSIG: "sig-node", Status: "provisional"},
+		{Title: "c", OwningSIG: "sig-node", Status: "implementable"},
+		{Title: "d", OwningSIG: "sig-node", Status: "implementable"},
+	}
+	var out strings.Builder
+	if err := printSIGSummary(&out, proposals); err != nil {
+		t.Fatal(err)
+	}
+	expected := `sig,provisional,implementable,implemented,deferred,rejected,withdrawn,replaced
+sig-node,1,2,0,0,0,0,0
+sig-storage,0,1,0,0,0,0,0
+`
+	if out.String() != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, out.String())
+	}
+}
+
+func TestPrintSearchIndex(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "template", OwningSIG: "sig-architecture", Filename: "YYYYMMDD-kep-template.md"},
+		{Title: "dry run", OwningSIG: "sig-api-machinery", Filename: "sig-api-machinery/0015-dry-run.md"},
+	}
+	var out strings.Builder
+	opts := outputOptions{baseURL: "https://example.com/keps/"}
+	if err := printSearchIndex(&out, proposals, opts); err != nil {
+		t.Fatal(err)
+	}
+	var records []keps.SearchRecord
+	if err := json.Unmarshal([]byte(out.String()), &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Title != "dry run" {
+		t.Fatalf("expected only the dry run KEP but got %+v", records)
+	}
+	if url := "https://example.com/keps/sig-api-machinery/0015-dry-run.md"; records[0].URL != url {
+		t.Fatalf("expected url %q but got %q", url, records[0].URL)
+	}
+}
+
+func TestFixKEP(t *testing.T) {
+	progress = io.Discard
+	dir := t.TempDir()
+	contents := "---\ntitle: test\nowning-sig: sig-apps\nparticipating-sigs:\n  - sig-apps\n  - sig-node\n---\n\nbody\n"
+	filename := filepath.Join(dir, "kep.md")
+	if err := os.WriteFile(filename, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	kep := (&keps.Parser{}).ParseFile(os.DirFS(dir), "kep.md")
+	if kep.Error != nil {
+		t.Fatal(kep.Error)
+	}
+	if err := fixKEP(&keps.Parser{}, kep, filename); err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(contents, "  - sig-apps\n", "", 1)
+	if string(fixed) != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, fixed)
+	}
+	if len(kep.ParticipatingSIGs) != 1 || kep.ParticipatingSIGs[0] != "sig-node" {
+		t.Fatalf("expected only sig-node to participate but got %v", kep.ParticipatingSIGs)
+	}
+}
+
+func TestFixKEPSymlink(t *testing.T) {
+	progress = io.Discard
+	dir := t.TempDir()
+	contents := "---\ntitle: test\nowning-sig: sig-apps\nparticipating-sigs:\n  - sig-apps\n  - sig-node\n---\n\nbody\n"
+	target := filepath.Join(dir, "target.md")
+	if err := os.WriteFile(target, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "kep.md")
+	if err := os.Symlink("target.md", filename); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	kep := (&keps.Parser{}).ParseFile(os.DirFS(dir), "kep.md")
+	if kep.Error != nil {
+		t.Fatal(kep.Error)
+	}
+	if err := fixKEP(&keps.Parser{}, kep, filename); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(filename); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected the KEP to still be a symlink (%v)", err)
+	}
+	fixed, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := strings.Replace(contents, "  - sig-apps\n", "", 1); string(fixed) != expected {
+		t.Fatalf("expected the target to be fixed as\n%s\nbut got\n%s", expected, fixed)
+	}
+}
+
+func TestFixKEPNormalizeLists(t *testing.T) {
+	progress = io.Discard
+	normalizeLists = true
+	defer func() { normalizeLists = false }()
+	dir := t.TempDir()
+	contents := "---\ntitle: test\nauthors: [\"@a\", \"@b\"]\nowning-sig: sig-apps\nparticipating-sigs: [sig-apps, sig-node]\n---\n\nauthors: [x]\n"
+	filename := filepath.Join(dir, "kep.md")
+	if err := os.WriteFile(filename, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	kep := (&keps.Parser{}).ParseFile(os.DirFS(dir), "kep.md")
+	if err := fixKEP(&keps.Parser{}, kep, filename); err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "---\ntitle: test\nauthors:\n  - \"@a\"\n  - \"@b\"\nowning-sig: sig-apps\nparticipating-sigs:\n  - sig-node\n---\n\nauthors: [x]\n"
+	if string(fixed) != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, fixed)
+	}
+}
+
+func TestPrintByStatus(t *testing.T) {
+	progress = io.Discard
+	proposals := keps.Proposals{
+		{Title: "a", OwningSIG: "sig-node", Status: "implementable"},
+		{Title: "b", OwningSIG: "sig-node", Status: "provisional"},
+		{Title: "c", OwningSIG: "sig-apps", Status: "implementable"},
+	}
+	for _, skipEmpty := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := printByStatus(dir, "jsonl", proposals, outputOptions{}, skipEmpty); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		expected := "implementable.jsonl,provisional.jsonl"
+		if !skipEmpty {
+			expected = "deferred.jsonl,implementable.jsonl,implemented.jsonl,provisional.jsonl,rejected.jsonl,replaced.jsonl,withdrawn.jsonl"
+		}
+		if strings.Join(names, ",") != expected {
+			t.Fatalf("expected the files %s but got %v", expected, names)
+		}
+		implementable, err := os.ReadFile(filepath.Join(dir, "implementable.jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Count(string(implementable), "\n"); lines != 2 {
+			t.Fatalf("expected 2 implementable KEPs but got:\n%s", implementable)
+		}
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "keps.json")
+	failed := fmt.Errorf("failed")
+	if err := writeOutput(output, func(w io.Writer) error {
+		fmt.Fprintln(w, "partial")
+		return failed
+	}); err != failed {
+		t.Fatalf("expected the write error but got %v", err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Fatalf("expected a failed write to leave nothing behind but got %v (%v)", entries, err)
+	}
+
+	if err := writeOutput(output, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, "complete")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if contents, err := os.ReadFile(output); err != nil || string(contents) != "complete\n" {
+		t.Fatalf("unexpected output %q (%v)", contents, err)
+	}
+}
+
+func TestWriteOutputMode(t *testing.T) {
+	dir := t.TempDir()
+	// os.Create applies the umask the same way
+	created, err := os.Create(filepath.Join(dir, "created"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created.Close()
+	umasked, err := os.Stat(created.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, "output")
+		return err
+	}
+
+	output := filepath.Join(dir, "keps.json")
+	if err := writeOutput(output, write); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(output); err != nil || info.Mode() != umasked.Mode() {
+		t.Fatalf("expected a new output to have mode %v but got %v (%v)", umasked.Mode(), info.Mode(), err)
+	}
+
+	if err := os.Chmod(output, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeOutput(output, write); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(output); err != nil || info.Mode() != 0600 {
+		t.Fatalf("expected the output to keep mode 0600 but got %v (%v)", info.Mode(), err)
+	}
+}
+
+func TestPrintVisibleDefaults(t *testing.T) {
+	fs := flag.NewFlagSet("kepify", flag.ContinueOnError)
+	fs.String("output", "keps.json", "output file")
+	fs.String("cpuprofile", "", "write a CPU profile")
+	fs.Set("output", "other.json")
+	var out strings.Builder
+	fs.SetOutput(&out)
+	printVisibleDefaults(fs)
+	if strings.Contains(out.String(), "cpuprofile") {
+		t.Errorf("expected the hidden flag to be left out but got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), `(default "keps.json")`) {
+		t.Errorf("expected the original default but got:\n%s", out.String())
+	}
+}
+
+func TestFixKEPSortLists(t *testing.T) {
+	progress = io.Discard
+	defer func() { noSortLists = false }()
+	contents := "---\ntitle: test\nauthors:\n  - \"@b\"\n  - \"@a\"\nowning-sig: sig-apps\n---\n"
+	for _, test := range []struct {
+		noSortLists bool
+		expected    string
+	}{
+		{false, "---\ntitle: test\nauthors:\n  - \"@a\"\n  - \"@b\"\nowning-sig: sig-apps\n---\n"},
+		{true, contents},
+	} {
+		noSortLists = test.noSortLists
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "kep.md")
+		if err := os.WriteFile(filename, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		kep := (&keps.Parser{}).ParseFile(os.DirFS(dir), "kep.md")
+		if err := fixKEP(&keps.Parser{}, kep, filename); err != nil {
+			t.Fatal(err)
+		}
+		fixed, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(fixed) != test.expected {
+			t.Errorf("with noSortLists %v expected\n%s\nbut got\n%s", test.noSortLists, test.expected, fixed)
+		}
+	}
+}
+
+func TestAppendToOutput(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "keps.json")
+	fresh := keps.Proposals{{Title: "a", OwningSIG: "sig-node", Status: "implementable"}}
+	merged, err := appendToOutput(filePath, fresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 1 {
+		t.Fatalf("expected the fresh KEPs without an output but got %v", merged)
+	}
+
+	existing := keps.Proposals{
+		{Title: "a", OwningSIG: "sig-node", Status: "provisional"},
+		{Title: "b", OwningSIG: "sig-node", Status: "provisional"},
+	}
+	if err := printOutput(filePath, "json", existing, outputOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	merged, err = appendToOutput(filePath, fresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 2 || merged[0].Status != "implementable" || merged[1].Title != "b" {
+		t.Fatalf("expected a to be replaced and b to be kept but got %v", merged)
+	}
+}
+
+func TestPrintGroupedStats(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "a", OwningSIG: "sig-node", LatestMilestone: "v1.10"},
+		{Title: "b", OwningSIG: "sig-api-machinery", LatestMilestone: "v1.9"},
+		{Title: "c", OwningSIG: "sig-node", LatestMilestone: "v1.10"},
+		{Title: "d", OwningSIG: "sig-node"},
+	}
+	testcases := []struct {
+		groupBy  string
+		expected string
+	}{
+		{"sig", "sig                KEPs\nsig-api-machinery  1\nsig-node           3\ntotal              4\n"},
+		{"milestone", "milestone  KEPs\nnone       1\nv1.9       1\nv1.10      2\ntotal      4\n"},
+	}
+	for _, tc := range testcases {
+		var out strings.Builder
+		if err := printGroupedStats(&out, proposals, tc.groupBy); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tc.expected {
+			t.Errorf("-group-by %s: expected\n%s\nbut got\n%s", tc.groupBy, tc.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"database/sql"
+
+	// pure go sqlite driver, so kepify does not need cgo
+	_ "modernc.org/sqlite"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+const sqliteSchema = `
+DROP TABLE IF EXISTS keps;
+CREATE TABLE keps (
+	hash               TEXT PRIMARY KEY,
+	title              TEXT,
+	owning_sig         TEXT,
+	participating_sigs TEXT,
+	reviewers          TEXT,
+	approvers          TEXT,
+	authors            TEXT,
+	editor             TEXT,
+	creation_date      TEXT,
+	last_updated       TEXT,
+	status             TEXT,
+	see_also           TEXT,
+	replaces           TEXT,
+	superseded_by      TEXT,
+	tracking_issue     TEXT,
+	path               TEXT,
+	markdown           TEXT
+);
+DROP TABLE IF EXISTS kepify;
+CREATE TABLE kepify (
+	version    TEXT,
+	git_commit TEXT,
+	build_date TEXT
+);`
+
+const sqliteBuildInfo = `INSERT INTO kepify (version, git_commit, build_date) VALUES (?, ?, ?)`
+
+const sqliteInsert = `
+INSERT INTO keps (
+	hash, title, owning_sig, participating_sigs, reviewers, approvers, authors, editor,
+	creation_date, last_updated, status, see_also, replaces, superseded_by, tracking_issue,
+	path, markdown
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// printSQLiteOutput writes one row per KEP into the keps table of the sqlite
+// database at filePath. The table is dropped and recreated on every run so
+// the database always reflects exactly the KEPs that were parsed. List
+// fields are stored as JSON text. Like in the json output, the path is only
+// set with -relative-paths and the markdown is left out with -no-body. The
+// kepify table records the version of
+// kepify that wrote the database.
+func printSQLiteOutput(filePath string, proposals keps.Proposals, opts outputOptions) error {
+	db, err := sql.Open("sqlite", filePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(sqliteSchema); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(sqliteBuildInfo, keps.Version, keps.Commit, keps.Date); err != nil {
+		tx.Rollback()
+		return err
+	}
+	stmt, err := tx.Prepare(sqliteInsert)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, kep := range proposals {
+		var markdown interface{} = kep.Contents
+		if opts.noBody {
+			markdown = nil
+		}
+		var filename interface{}
+		if opts.paths {
+			filename = kep.Filename
+		}
+		_, err := stmt.Exec(
+			kep.Hash(),
+			kep.Title,
+			kep.OwningSIG,
+			marshal(kep.ParticipatingSIGs),
+			marshal(kep.Reviewers),
+			marshal(kep.Approvers),
+			marshal(kep.Authors),
+			kep.Editor,
+			kep.CreationDate,
+			kep.LastUpdated,
+			kep.Status,
+			marshal(kep.SeeAlso),
+			marshal(kep.Replaces),
+			marshal(kep.SupersededBy),
+			kep.TrackingIssue,
+			filename,
+			markdown,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
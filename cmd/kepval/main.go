@@ -19,6 +19,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"k8s.io/enhancements/pkg/kepval/keps"
 )
@@ -30,20 +31,19 @@ func main() {
 func run() int {
 	parser := &keps.Parser{}
 	for _, filename := range os.Args[1:] {
-		file, err := os.Open(filename)
-		if err != nil {
-			fmt.Printf("could not open file: %v", err)
-			return 1
+		// metadata may also come from a kep.yaml next to the file
+		result := parser.ParseFileResult(os.DirFS(filepath.Dir(filename)), filepath.Base(filename))
+		// warnings go to stdout and errors to stderr
+		for _, warning := range result.Warnings {
+			fmt.Printf("%v has a warning: %q\n", filename, warning.Message)
 		}
-		defer file.Close()
-		kep := parser.Parse(file)
-		// if error is nil we can move on
-		if kep.Error == nil {
-			continue
+		for _, err := range result.Errors {
+			fmt.Fprintf(os.Stderr, "%v has an error: %q\n", filename, err.Message)
+		}
+		// if there are no errors we can move on
+		if len(result.Errors) > 0 {
+			return 1
 		}
-
-		fmt.Printf("%v has an error: %q\n", filename, kep.Error.Error())
-		return 1
 	}
 
 	fmt.Printf("No validation errors : %v\n", os.Args[1:])
@@ -0,0 +1,382 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Heading is a markdown heading in the body of a KEP.
+type Heading struct {
+	Level int
+	Text  string
+	// Anchor is the fragment GitHub generates for the heading, including
+	// the numeric suffix it adds to repeated headings.
+	Anchor string
+	// Line is the line number of the heading in the KEP file.
+	Line int
+}
+
+var (
+	reHeading    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	reFence      = regexp.MustCompile("^\\s*(`{3,}|~{3,})(.*)$")
+	reLink       = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	reHTMLTag    = regexp.MustCompile(`<[^>]*>`)
+	reAnchorLink = regexp.MustCompile(`\]\(#([^)\s]*)\)|href="#([^"]*)"`)
+	reUnchecked  = regexp.MustCompile(`^\s*[-*+]\s+\[ \]`)
+	reImage      = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)`)
+	reImgTag     = regexp.MustCompile(`(?i)<img\s[^>]*\bsrc\s*=\s*["']([^"']+)["']`)
+	reURLScheme  = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	reMention    = regexp.MustCompile(`(?:^|[^\w@/])@([A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)`)
+)
+
+// Headings returns the outline of the KEP body in document order. Headings
+// inside fenced code blocks are ignored.
+func (p *Proposal) Headings() []Heading {
+	var headings []Heading
+	seen := map[string]int{}
+	p.eachLine(func(line string, number int) {
+		match := reHeading.FindStringSubmatch(line)
+		if match == nil {
+			return
+		}
+		text := match[2]
+		anchor := Slug(text)
+		if n, ok := seen[anchor]; ok {
+			seen[anchor] = n + 1
+			anchor = fmt.Sprintf("%s-%d", anchor, n+1)
+		} else {
+			seen[anchor] = 0
+		}
+		headings = append(headings, Heading{
+			Level:  len(match[1]),
+			Text:   text,
+			Anchor: anchor,
+			Line:   number,
+		})
+	})
+	return headings
+}
+
+// Anchors returns the set of in-page anchors generated for the headings of
+// the KEP body.
+func (p *Proposal) Anchors() map[string]bool {
+	anchors := map[string]bool{}
+	for _, heading := range p.Headings() {
+		anchors[heading.Anchor] = true
+	}
+	return anchors
+}
+
+// anchorLink is a link in the KEP body to an anchor within the same KEP.
+type anchorLink struct {
+	anchor string
+	line   int
+}
+
+func (p *Proposal) anchorLinks() []anchorLink {
+	var links []anchorLink
+	p.eachLine(func(line string, number int) {
+		for _, match := range reAnchorLink.FindAllStringSubmatch(line, -1) {
+			anchor := match[1]
+			if anchor == "" {
+				anchor = match[2]
+			}
+			links = append(links, anchorLink{anchor, number})
+		}
+	})
+	return links
+}
+
+// Slug returns the anchor GitHub generates for a heading with the given
+// markdown text: formatting is dropped, letters are lowercased, spaces
+// become hyphens and any other punctuation is removed.
+func Slug(text string) string {
+	text = reLink.ReplaceAllString(text, "$1")
+	text = reHTMLTag.ReplaceAllString(text, "")
+	var slug strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), r == '-', r == '_':
+			slug.WriteRune(r)
+		case r == ' ':
+			slug.WriteRune('-')
+		}
+	}
+	return slug.String()
+}
+
+// fenceState follows the fenced code blocks of a markdown text line by
+// line, the way CommonMark does. A fence is a line of at least three
+// backticks or tildes; the info string after backticks may not contain a
+// backtick, so a line such as ```x``` is inline code. A block only ends at a
+// fence of the same character, at least as long as the one that opened it,
+// with nothing after it.
+type fenceState struct {
+	// open is the fence of the current block, or empty outside of one.
+	open string
+}
+
+// next reports whether line opens or closes a fenced code block, along with
+// the info string of an opening fence.
+func (f *fenceState) next(line string) (fence bool, info string) {
+	match := reFence.FindStringSubmatch(line)
+	if f.open == "" {
+		if match == nil || match[1][0] == '`' && strings.Contains(match[2], "`") {
+			return false, ""
+		}
+		f.open = match[1]
+		return true, strings.TrimSpace(match[2])
+	}
+	if match == nil || match[1][0] != f.open[0] || len(match[1]) < len(f.open) || strings.TrimSpace(match[2]) != "" {
+		return false, ""
+	}
+	f.open = ""
+	return true, ""
+}
+
+// inside reports whether the lines after the last one passed to next are
+// inside a fenced code block.
+func (f *fenceState) inside() bool {
+	return f.open != ""
+}
+
+// eachLine calls fn with every line of the KEP body that is not inside a
+// fenced code block, along with its line number in the KEP file.
+func (p *Proposal) eachLine(fn func(line string, number int)) {
+	var fences fenceState
+	for i, line := range strings.Split(p.Contents, "\n") {
+		if fence, _ := fences.next(line); fence || fences.inside() {
+			continue
+		}
+		fn(line, p.fileLine(i))
+	}
+}
+
+// CodeFence is the opening line of a fenced code block in the body of a KEP.
+type CodeFence struct {
+	// Language is the first word of the info string, such as yaml, or empty
+	// if the fence does not name one.
+	Language string
+	// Line is the line number of the fence in the KEP file.
+	Line int
+	// Code is the text of the block, without its fences.
+	Code string
+}
+
+// CodeFences returns the opening fence of every fenced code block in the KEP
+// body.
+func (p *Proposal) CodeFences() []CodeFence {
+	var fences []CodeFence
+	var code strings.Builder
+	var state fenceState
+	for i, line := range strings.Split(p.Contents, "\n") {
+		fence, info := state.next(line)
+		switch {
+		case fence && state.inside():
+			var language string
+			if words := strings.Fields(info); len(words) > 0 {
+				language = words[0]
+			}
+			fences = append(fences, CodeFence{Language: language, Line: p.fileLine(i)})
+			code.Reset()
+		case fence:
+			fences[len(fences)-1].Code = code.String()
+		case state.inside():
+			code.WriteString(line + "\n")
+		}
+	}
+	if state.inside() {
+		fences[len(fences)-1].Code = code.String()
+	}
+	return fences
+}
+
+// fileLine returns the line number in the KEP file of the given zero based
+// line of Contents.
+func (p *Proposal) fileLine(i int) int {
+	if i < len(p.bodyLines) {
+		return p.bodyLines[i]
+	}
+	return i + 1
+}
+
+// HasSection reports whether the KEP body has a heading whose text matches
+// one of titles, ignoring case.
+func (p *Proposal) HasSection(titles ...string) bool {
+	for _, heading := range p.Headings() {
+		for _, title := range titles {
+			if strings.EqualFold(strings.TrimSpace(heading.Text), title) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Subsections returns the headings nested under every heading whose text
+// matches title, ignoring case, in document order.
+func (p *Proposal) Subsections(title string) []Heading {
+	var subsections []Heading
+	level := 0
+	for _, heading := range p.Headings() {
+		switch {
+		case strings.EqualFold(strings.TrimSpace(heading.Text), title):
+			level = heading.Level
+		case heading.Level <= level:
+			level = 0
+		case level > 0:
+			subsections = append(subsections, heading)
+		}
+	}
+	return subsections
+}
+
+// UncheckedItems returns the line numbers of the unchecked task list items,
+// such as "- [ ] beta", in every section whose heading matches title,
+// ignoring case. A section ends at the next heading of the same or a higher
+// level.
+func (p *Proposal) UncheckedItems(title string) []int {
+	var lines []int
+	p.eachSectionLine(title, func(line string, number int) {
+		if reUnchecked.MatchString(line) {
+			lines = append(lines, number)
+		}
+	})
+	return lines
+}
+
+// MarkerLines returns the line numbers of the lines that contain one of
+// markers, such as TODO, as a whole word. Fenced code blocks are skipped.
+func (p *Proposal) MarkerLines(markers ...string) []int {
+	if len(markers) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(markers))
+	for i, marker := range markers {
+		quoted[i] = regexp.QuoteMeta(marker)
+	}
+	reMarker := regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	var lines []int
+	p.eachLine(func(line string, number int) {
+		if reMarker.MatchString(line) {
+			lines = append(lines, number)
+		}
+	})
+	return lines
+}
+
+// Image is an image embedded in the body of a KEP, with markdown such as
+// ![diagram](diagram.png) or an HTML <img> tag.
+type Image struct {
+	Src string
+	// Line is the line number of the image in the KEP file.
+	Line int
+}
+
+// Images returns the images embedded in the KEP body, in document order.
+// Fenced code blocks are skipped.
+func (p *Proposal) Images() []Image {
+	var images []Image
+	p.eachLine(func(line string, number int) {
+		for _, re := range []*regexp.Regexp{reImage, reImgTag} {
+			for _, match := range re.FindAllStringSubmatch(line, -1) {
+				images = append(images, Image{Src: match[1], Line: number})
+			}
+		}
+	})
+	return images
+}
+
+// IsRelative reports whether the image refers to a path relative to the
+// KEP, rather than to a URL or an absolute path.
+func (i Image) IsRelative() bool {
+	return !reURLScheme.MatchString(i.Src) && !strings.HasPrefix(i.Src, "/") && !strings.HasPrefix(i.Src, "#")
+}
+
+// Mention is a GitHub handle mentioned in the body of a KEP.
+type Mention struct {
+	Handle string
+	// Line is the line number of the mention in the KEP file.
+	Line int
+}
+
+// Mentions returns the GitHub handles, such as @jpbetz, mentioned in every
+// section whose heading matches title, ignoring case.
+func (p *Proposal) Mentions(title string) []Mention {
+	var mentions []Mention
+	p.eachSectionLine(title, func(line string, number int) {
+		for _, match := range reMention.FindAllStringSubmatch(line, -1) {
+			mentions = append(mentions, Mention{Handle: "@" + match[1], Line: number})
+		}
+	})
+	return mentions
+}
+
+// eachSectionLine calls fn with every line that eachLine visits in the
+// sections whose heading matches title, ignoring case. Subsections are part
+// of the section.
+func (p *Proposal) eachSectionLine(title string, fn func(line string, number int)) {
+	level := 0
+	p.eachLine(func(line string, number int) {
+		if match := reHeading.FindStringSubmatch(line); match != nil {
+			switch {
+			case strings.EqualFold(strings.TrimSpace(match[2]), title):
+				level = len(match[1])
+			case len(match[1]) <= level:
+				level = 0
+			}
+			return
+		}
+		if level > 0 {
+			fn(line, number)
+		}
+	})
+}
+
+// Summary returns the text of the "## Summary" section of the KEP body, up
+// to the next heading, with leading and trailing whitespace trimmed. It
+// returns an empty string if the KEP has no Summary section.
+func (p *Proposal) Summary() string {
+	var lines []string
+	var fences fenceState
+	inSummary := false
+	for _, line := range strings.Split(p.Contents, "\n") {
+		fence, _ := fences.next(line)
+		// headings in code blocks do not end the summary
+		if match := reHeading.FindStringSubmatch(line); match != nil && !fence && !fences.inside() {
+			if inSummary {
+				break
+			}
+			inSummary = len(match[1]) == 2 && strings.EqualFold(strings.TrimSpace(match[2]), "Summary")
+			continue
+		}
+		if inSummary {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// StripLinks replaces the markdown links and images in text, such as
+// [KEP](https://example.com), with their link text.
+func StripLinks(text string) string {
+	return reLink.ReplaceAllString(text, "$1")
+}
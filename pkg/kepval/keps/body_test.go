@@ -0,0 +1,649 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestSlug(t *testing.T) {
+	testcases := []struct {
+		text     string
+		expected string
+	}{
+		{"Summary", "summary"},
+		{"Design Details", "design-details"},
+		{"Use of `node-role.kubernetes.io/*` labels", "use-of-node-rolekubernetesio-labels"},
+		{"[Link](https://example.com) heading", "link-heading"},
+		{"Non-Goals", "non-goals"},
+		{"Alpha -> Beta Graduation", "alpha---beta-graduation"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.text, func(t *testing.T) {
+			if slug := keps.Slug(tc.text); slug != tc.expected {
+				t.Fatalf("expected %q but got %q", tc.expected, slug)
+			}
+		})
+	}
+}
+
+func TestHeadings(t *testing.T) {
+	contents := `---
+title: test
+owning-sig: sig-api-machinery
+---
+# Title
+
+## Summary
+
+` + "```" + `
+# not a heading
+` + "```" + `
+
+### Notes
+### Notes
+`
+	p := &keps.Parser{}
+	out := p.Parse(strings.NewReader(contents))
+	expected := []keps.Heading{
+		{Level: 1, Text: "Title", Anchor: "title", Line: 5},
+		{Level: 2, Text: "Summary", Anchor: "summary", Line: 7},
+		{Level: 3, Text: "Notes", Anchor: "notes", Line: 13},
+		{Level: 3, Text: "Notes", Anchor: "notes-1", Line: 14},
+	}
+	headings := out.Headings()
+	if len(headings) != len(expected) {
+		t.Fatalf("expected %d headings but got %v", len(expected), headings)
+	}
+	for i := range expected {
+		if headings[i] != expected[i] {
+			t.Fatalf("expected %v but got %v", expected[i], headings[i])
+		}
+	}
+}
+
+func TestFences(t *testing.T) {
+	// written with ' for `, which cannot be part of a raw string
+	contents := strings.ReplaceAll(`---
+title: test
+owning-sig: sig-api-machinery
+---
+## Summary
+
+'''x''' is inline code.
+
+## After inline code
+
+''''markdown
+'''yaml
+# not a heading
+'''
+'''' not a closing fence
+''''
+
+~~~
+'''
+~~~
+
+## After the blocks
+`, "'", "`")
+	p := &keps.Parser{}
+	out := p.Parse(strings.NewReader(contents))
+	var headings []string
+	for _, heading := range out.Headings() {
+		headings = append(headings, heading.Text)
+	}
+	if expected := "Summary,After inline code,After the blocks"; strings.Join(headings, ",") != expected {
+		t.Errorf("expected the headings %s but got %v", expected, headings)
+	}
+	fences := out.CodeFences()
+	if len(fences) != 2 || fences[0].Language != "markdown" || fences[0].Line != 11 || fences[1].Language != "" || fences[1].Line != 18 {
+		t.Fatalf("expected a markdown block at line 11 and a plain one at line 18 but got %+v", fences)
+	}
+	if expected := "```yaml\n# not a heading\n```\n```` not a closing fence\n"; fences[0].Code != expected {
+		t.Errorf("expected the code %q but got %q", expected, fences[0].Code)
+	}
+	if summary := out.Summary(); summary != "```x``` is inline code." {
+		t.Errorf("expected the summary to end at the next heading but got %q", summary)
+	}
+}
+
+func TestValidateAnchors(t *testing.T) {
+	// the repeated headings below are also reported by duplicate-anchors
+	defer keps.EnableValidators()
+	if err := keps.EnableValidators("anchors"); err != nil {
+		t.Fatal(err)
+	}
+	testcases := []struct {
+		name       string
+		contents   string
+		expectWarn bool
+	}{
+		{
+			name:     "link to existing heading",
+			contents: "- [Summary](#summary)\n\n## Summary\n",
+		},
+		{
+			name:     "link to repeated heading",
+			contents: "- [Notes](#notes-1)\n\n## Notes\n## Notes\n",
+		},
+		{
+			name:       "dangling link",
+			contents:   "- [Motivation](#motivation)\n\n## Summary\n",
+			expectWarn: true,
+		},
+		{
+			name:       "dangling html link",
+			contents:   "<a href=\"#motivation\">Motivation</a>\n\n## Summary\n",
+			expectWarn: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.Contents = tc.contents + p.Contents
+			errs := p.Validate()
+			if tc.expectWarn && (len(errs) != 1 || !strings.Contains(errs[0].Error(), "#motivation")) {
+				t.Fatalf("expected a warning about #motivation but got %v", errs)
+			}
+			if !tc.expectWarn && len(errs) != 0 {
+				t.Fatalf("did not expect an error: %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidateHeadingLevels(t *testing.T) {
+	testcases := []struct {
+		name       string
+		contents   string
+		expectWarn bool
+	}{
+		{
+			name:     "nested one level at a time",
+			contents: "# Title\n## Summary\n### Goals\n## Proposal\n",
+		},
+		{
+			name:     "going back up several levels",
+			contents: "# Title\n## Design\n### Details\n#### More\n## Proposal\n",
+		},
+		{
+			name:     "first heading may be at any level",
+			contents: "## Summary\n### Goals\n",
+		},
+		{
+			name:       "skipped level",
+			contents:   "# Title\n### Summary\n",
+			expectWarn: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.Contents = tc.contents + p.Contents
+			errs := p.Validate()
+			if tc.expectWarn && (len(errs) != 1 || !strings.Contains(errs[0].Error(), "line 2")) {
+				t.Fatalf("expected a warning about line 2 but got %v", errs)
+			}
+			if !tc.expectWarn && len(errs) != 0 {
+				t.Fatalf("did not expect an error: %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidateDuplicateAnchors(t *testing.T) {
+	testcases := []struct {
+		name     string
+		contents string
+		expected []string
+	}{
+		{
+			name:     "distinct headings",
+			contents: "# Title\n## Alpha\n## Beta\n",
+		},
+		{
+			name:     "repeated heading",
+			contents: "# Title\n## Graduation\n### Alpha\n## Upgrade\n### Alpha\n",
+			expected: []string{`line 5 has the heading "Alpha", whose anchor #alpha is already used by the heading on line 3; GitHub links to it as #alpha-1`},
+		},
+		{
+			name:     "headings with the same anchor",
+			contents: "# Title\n## Non-Goals\n## Non Goals\n## non-goals\n",
+			expected: []string{
+				`line 3 has the heading "Non Goals", whose anchor #non-goals is already used by the heading on line 2; GitHub links to it as #non-goals-1`,
+				`line 4 has the heading "non-goals", whose anchor #non-goals is already used by the heading on line 2; GitHub links to it as #non-goals-2`,
+			},
+		},
+		{
+			name:     "headings in code blocks",
+			contents: "# Title\n## Alpha\n```\n## Alpha\n```\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.Contents = tc.contents + p.Contents
+			errs := p.Validate()
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("expected %d warnings but got %v", len(tc.expected), errs)
+			}
+			for i, err := range errs {
+				if !keps.IsWarning(err) || err.Error() != tc.expected[i] {
+					t.Errorf("expected the warning %q but got %v", tc.expected[i], err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateGraduationCriteria(t *testing.T) {
+	testcases := []struct {
+		name       string
+		status     string
+		contents   string
+		expectWarn bool
+	}{
+		{
+			name:     "implemented with every criterion checked",
+			status:   "implemented",
+			contents: "# Title\n## Graduation Criteria\n- [x] alpha\n- [X] beta\n",
+		},
+		{
+			name:       "implemented with an unchecked criterion",
+			status:     "implemented",
+			contents:   "# Title\n## Graduation Criteria\n- [x] alpha\n### Beta\n- [ ] e2e tests\n",
+			expectWarn: true,
+		},
+		{
+			name:     "unchecked items in other sections",
+			status:   "implemented",
+			contents: "# Title\n## Graduation Criteria\n- [x] alpha\n## Test Plan\n- [ ] e2e tests\n",
+		},
+		{
+			name:     "unchecked items in a code block",
+			status:   "implemented",
+			contents: "# Title\n## Graduation Criteria\n```\n- [ ] alpha\n```\n",
+		},
+		{
+			name:     "not implemented yet",
+			status:   "implementable",
+			contents: "# Title\n## Graduation Criteria\n- [ ] alpha\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.Status = tc.status
+			p.Reviewers = []string{"@lavalamp"}
+			p.Contents = tc.contents + p.Contents
+			errs := p.Validate()
+			if tc.expectWarn && (len(errs) != 1 || !keps.IsWarning(errs[0]) || !strings.Contains(errs[0].Error(), "line 5")) {
+				t.Fatalf("expected a warning about line 5 but got %v", errs)
+			}
+			if !tc.expectWarn && len(errs) != 0 {
+				t.Fatalf("did not expect an error: %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidateGraduationStages(t *testing.T) {
+	testcases := []struct {
+		name      string
+		milestone map[string]string
+		contents  string
+		missing   []string
+	}{
+		{
+			name:      "every stage has a subsection",
+			milestone: map[string]string{"alpha": "v1.15", "beta": "v1.16", "stable": "v1.18"},
+			contents:  "# Title\n## Graduation Criteria\n### Alpha\n### Alpha -> Beta Graduation\n#### Beta -> GA\n",
+		},
+		{
+			name:      "missing stages",
+			milestone: map[string]string{"alpha": "v1.15", "beta": "v1.16", "stable": "v1.18"},
+			contents:  "# Title\n## Graduation Criteria\n### Alpha\n## Beta\n### Stable\n",
+			missing:   []string{"beta", "stable"},
+		},
+		{
+			name:      "only stages in the milestone map",
+			milestone: map[string]string{"alpha": "v1.15"},
+			contents:  "# Title\n## Graduation Criteria\n### Alpha\n",
+		},
+		{
+			name:      "no Graduation Criteria section",
+			milestone: map[string]string{"alpha": "v1.15"},
+			contents:  "# Title\n## Test Plan\n",
+		},
+		{
+			name:      "alphabet is not alpha",
+			milestone: map[string]string{"alpha": "v1.15"},
+			contents:  "# Title\n## Graduation Criteria\n### Alphabet\n",
+			missing:   []string{"alpha"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.Milestone = tc.milestone
+			// beta and stable milestones also need the PRR questionnaire
+			p.Contents = tc.contents + "## Production Readiness Review Questionnaire\n" + p.Contents
+			errs := p.Validate()
+			if len(errs) != len(tc.missing) {
+				t.Fatalf("expected warnings for %v but got %v", tc.missing, errs)
+			}
+			for i, stage := range tc.missing {
+				if !keps.IsWarning(errs[i]) || !strings.HasPrefix(errs[i].Error(), "a "+stage+" milestone") {
+					t.Errorf("expected a warning for %s but got %q", stage, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateBodyPeople(t *testing.T) {
+	defer func(check bool) { keps.CheckBodyPeople = check }(keps.CheckBodyPeople)
+
+	testcases := []struct {
+		name     string
+		check    bool
+		contents string
+		expected []string
+	}{
+		{
+			name:     "mentioned people are listed",
+			check:    true,
+			contents: "# Title\n## Reviewers\n- @Deads2k\n- @lavalamp, see me@example.com\n## Approvers\n@liggitt\n",
+		},
+		{
+			name:     "people outside the sections are ignored",
+			check:    true,
+			contents: "# Title\n## Summary\nThanks @sttts\n## Reviewers\n- @deads2k\n",
+		},
+		{
+			name:     "reviewer and approver missing from metadata",
+			check:    true,
+			contents: "# Title\n## Reviewers\n- @deads2k\n### Backup\n- @sttts\n## Approvers\n- @thockin\n",
+			expected: []string{`line 5 mentions @sttts but "reviewers"`, `line 7 mentions @thockin but "approvers"`},
+		},
+		{
+			name:     "check is off by default",
+			contents: "# Title\n## Reviewers\n- @sttts\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			keps.CheckBodyPeople = tc.check
+			p := validProposal()
+			p.Reviewers = []string{"@deads2k", "lavalamp"}
+			p.Approvers = []string{"@liggitt"}
+			p.Contents = tc.contents + p.Contents
+			errs := p.Validate()
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("expected %d warnings but got %v", len(tc.expected), errs)
+			}
+			for i, expected := range tc.expected {
+				if !keps.IsWarning(errs[i]) || !strings.Contains(errs[i].Error(), expected) {
+					t.Errorf("expected a warning containing %q but got %v", expected, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCodeLanguages(t *testing.T) {
+	defer func(check bool) { keps.CheckCodeLanguages = check }(keps.CheckCodeLanguages)
+
+	testcases := []struct {
+		name     string
+		check    bool
+		contents string
+		expected []string
+	}{
+		{
+			name:     "known and unnamed languages",
+			check:    true,
+			contents: "# Title\n```YAML\nkey: value\n```\n~~~\nplain\n~~~\n",
+		},
+		{
+			name:     "typo in a language",
+			check:    true,
+			contents: "# Title\n```yam\nkey: value\n```\n\n```go\n```\n  ```jsn\n  ```\n",
+			expected: []string{`line 2 opens a code block in unknown language "yam"`, `line 8 opens a code block in unknown language "jsn"`},
+		},
+		{
+			name:     "check is off by default",
+			contents: "# Title\n```yam\n```\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			keps.CheckCodeLanguages = tc.check
+			p := validProposal()
+			p.Contents = tc.contents + p.Contents
+			errs := p.Validate()
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("expected %d warnings but got %v", len(tc.expected), errs)
+			}
+			for i, expected := range tc.expected {
+				if !keps.IsWarning(errs[i]) || !strings.Contains(errs[i].Error(), expected) {
+					t.Errorf("expected a warning containing %q but got %v", expected, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTodos(t *testing.T) {
+	testcases := []struct {
+		name     string
+		status   string
+		stage    string
+		contents string
+		expected string
+	}{
+		{
+			name:     "implemented with markers",
+			status:   "implemented",
+			contents: "# Title\nTODO: write this\nFIXME.\n```\n// TODO in an example\n```\nXXX\n",
+			expected: "status is implemented, but the body still has TODO/FIXME/XXX markers on lines 2, 3, 7",
+		},
+		{
+			name:     "stable with markers",
+			status:   "provisional",
+			stage:    "stable",
+			contents: "# Title\nTODO\n## Production Readiness Review Questionnaire\n",
+			expected: "stage is stable, but the body still has TODO/FIXME/XXX markers on line 2",
+		},
+		{
+			name:     "markers must be whole words",
+			status:   "implemented",
+			contents: "# Title\nTODOS and XXXL\n",
+		},
+		{
+			name:     "earlier stages may have markers",
+			status:   "provisional",
+			stage:    "beta",
+			contents: "# Title\n## Production Readiness Review Questionnaire\nTODO\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.Status = tc.status
+			p.Stage = tc.stage
+			p.Contents = tc.contents + p.Contents
+			errs := p.Validate()
+			if tc.expected == "" {
+				if len(errs) != 0 {
+					t.Fatalf("did not expect an error: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !keps.IsWarning(errs[0]) || errs[0].Error() != tc.expected {
+				t.Fatalf("expected a warning %q but got %v", tc.expected, errs)
+			}
+		})
+	}
+}
+
+func TestValidateImages(t *testing.T) {
+	defer func(minWords int) { keps.MinWordCount = minWords }(keps.MinWordCount)
+	keps.MinWordCount = 0
+
+	const kep = `---
+title: test
+authors:
+  - "@jpbetz"
+owning-sig: sig-api-machinery
+status: provisional
+---
+# Images
+![found](images/ok.png "title")
+![spaces](my%20diagram.png) and ![missing](images/missing.png)
+<p><IMG width="10" src="../other/gone.png"></p>
+![remote](https://example.com/remote.png) ![absolute](/logo.png)
+` + "```" + `
+![in code](images/code.png)
+` + "```" + `
+`
+	fsys := fstest.MapFS{
+		"sig-node/kep/README.md":      {Data: []byte(kep)},
+		"sig-node/kep/images/ok.png":  {},
+		"sig-node/kep/my diagram.png": {},
+	}
+	p := (&keps.Parser{}).ParseFile(fsys, "sig-node/kep/README.md")
+	if p.Error != nil {
+		t.Fatal(p.Error)
+	}
+	if images := p.Images(); len(images) != 6 || images[3].Src != "../other/gone.png" || images[3].Line != 11 {
+		t.Fatalf("unexpected images %v", images)
+	}
+	expected := []string{
+		`line 10 embeds image "images/missing.png", which does not exist`,
+		`line 11 embeds image "../other/gone.png", which does not exist`,
+	}
+	errs := p.Validate()
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d warnings but got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if !keps.IsWarning(err) || err.Error() != expected[i] {
+			t.Errorf("expected a warning %q but got %v", expected[i], err)
+		}
+	}
+
+	// proposals that were not read from a file system are not checked
+	p = validProposal()
+	p.Contents = "![missing](missing.png)\n" + p.Contents
+	if errs := p.Validate(); len(errs) != 0 {
+		t.Fatalf("did not expect an error: %v", errs)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	testcases := []struct {
+		name     string
+		contents string
+		expected string
+	}{
+		{
+			name:     "no summary",
+			contents: "# Title\n\n## Motivation\n\ntext\n",
+			expected: "",
+		},
+		{
+			name:     "up to the next heading",
+			contents: "# Title\n\n## Summary\n\n  First line.\nSecond line.\n\n### Details\n\nmore\n",
+			expected: "First line.\nSecond line.",
+		},
+		{
+			name:     "headings in code blocks",
+			contents: "## summary\n\n```\n# not a heading\n```\n\n## Motivation\n",
+			expected: "```\n# not a heading\n```",
+		},
+		{
+			name:     "only level two",
+			contents: "### Summary\n\nnested\n\n## Summary\n\ntop\n",
+			expected: "top",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &keps.Proposal{Contents: tc.contents}
+			if summary := p.Summary(); summary != tc.expected {
+				t.Fatalf("expected %q but got %q", tc.expected, summary)
+			}
+		})
+	}
+}
+
+func TestStripLinks(t *testing.T) {
+	text := "See [the KEP](https://example.com/kep) and ![a diagram](diagram.png)."
+	expected := "See the KEP and a diagram."
+	if stripped := keps.StripLinks(text); stripped != expected {
+		t.Fatalf("expected %q but got %q", expected, stripped)
+	}
+}
+
+func TestValidateCodeExamples(t *testing.T) {
+	defer func(check bool) { keps.CheckCodeExamples = check }(keps.CheckCodeExamples)
+
+	testcases := []struct {
+		name     string
+		check    bool
+		contents string
+		expected []string
+	}{
+		{
+			name:     "valid examples",
+			check:    true,
+			contents: "# Title\n```yaml\napiVersion: v1\nkind: Pod\n---\nkind: Service\n```\n```json\n{\"kind\": \"Pod\"}\n```\n```go\nnot yaml: [\n```\n",
+		},
+		{
+			name:     "broken examples",
+			check:    true,
+			contents: "# Title\n```yaml\nkey: [value\n```\n\n```YML\na: b\n---\n\tc: d\n```\n```json\n{\"kind\": }\n```\n",
+			expected: []string{"the yaml code block opened on line 2 does not parse", "the YML code block opened on line 6 does not parse", "the json code block opened on line 11 does not parse"},
+		},
+		{
+			name:     "check is off by default",
+			contents: "# Title\n```yaml\nkey: [value\n```\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			keps.CheckCodeExamples = tc.check
+			p := validProposal()
+			p.Contents = tc.contents + p.Contents
+			errs := p.Validate()
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("expected %d warnings but got %v", len(tc.expected), errs)
+			}
+			for i, expected := range tc.expected {
+				if !keps.IsWarning(errs[i]) || !strings.Contains(errs[i].Error(), expected) {
+					t.Errorf("expected a warning containing %q but got %v", expected, errs[i])
+				}
+			}
+		})
+	}
+}
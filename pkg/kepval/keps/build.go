@@ -0,0 +1,49 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Version, Commit and Date describe the build of the KEP tools. They are
+// set at build time, e.g.
+//
+//	go build -ldflags "-X k8s.io/enhancements/pkg/kepval/keps.Version=v0.1.0 \
+//	  -X k8s.io/enhancements/pkg/kepval/keps.Commit=$(git rev-parse HEAD) \
+//	  -X k8s.io/enhancements/pkg/kepval/keps.Date=$(date -u +%Y-%m-%d)" ./cmd/kepify
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// BuildInfo returns Version along with Commit and Date, if they are set.
+func BuildInfo() string {
+	var details []string
+	if Commit != "" {
+		details = append(details, "commit "+Commit)
+	}
+	if Date != "" {
+		details = append(details, "built "+Date)
+	}
+	if len(details) == 0 {
+		return Version
+	}
+	return fmt.Sprintf("%s (%s)", Version, strings.Join(details, ", "))
+}
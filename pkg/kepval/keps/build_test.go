@@ -0,0 +1,44 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestBuildInfo(t *testing.T) {
+	defer func(version, commit, date string) {
+		keps.Version, keps.Commit, keps.Date = version, commit, date
+	}(keps.Version, keps.Commit, keps.Date)
+
+	testcases := []struct {
+		version, commit, date string
+		expected              string
+	}{
+		{"dev", "", "", "dev"},
+		{"v0.1.0", "abc123", "", "v0.1.0 (commit abc123)"},
+		{"v0.1.0", "abc123", "2020-01-01", "v0.1.0 (commit abc123, built 2020-01-01)"},
+	}
+	for _, tc := range testcases {
+		keps.Version, keps.Commit, keps.Date = tc.version, tc.commit, tc.date
+		if info := keps.BuildInfo(); info != tc.expected {
+			t.Errorf("expected %q but got %q", tc.expected, info)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ToDOT writes the references between the proposals to w as a Graphviz DOT
+// graph. Every proposal is a node, labeled by its number, if it has one, and
+// its title. Every entry of replaces, superseded-by and see-also is an edge
+// from the proposal that lists it, labeled by the key; repeated entries are
+// drawn once. References that do not resolve, see Resolve, point to a dashed
+// node labeled by the reference. The Filename of each proposal must be its
+// path relative to the KEP directory.
+func (p Proposals) ToDOT(w io.Writer) error {
+	return p.ToDOTIn(w, p)
+}
+
+// ToDOTIn is like ToDOT, but the references are resolved against tree,
+// which holds p along with the other KEPs. The proposals of tree that are
+// not in p are only drawn, in gray, when one of p refers to them.
+func (p Proposals) ToDOTIn(w io.Writer, tree Proposals) error {
+	var b strings.Builder
+	b.WriteString("digraph keps {\n")
+	for _, proposal := range p {
+		fmt.Fprintf(&b, "\t%s [label=%s];\n", dotQuote(proposal.Filename), dotQuote(dotLabel(proposal)))
+	}
+	var edges []string
+	drawn := p.set()
+	dangling, seen := map[string]bool{}, map[string]bool{}
+	for _, proposal := range p {
+		references := []struct {
+			key  string
+			refs []string
+		}{
+			{"replaces", proposal.Replaces},
+			{"superseded-by", proposal.SupersededBy},
+			{"see-also", proposal.SeeAlso},
+		}
+		for _, reference := range references {
+			for _, ref := range reference.refs {
+				to := "unresolved:" + strings.TrimSpace(ref)
+				if target := tree.Resolve(ref); target != nil {
+					to = target.Filename
+					if !drawn[target] {
+						drawn[target] = true
+						fmt.Fprintf(&b, "\t%s [label=%s, color=gray];\n", dotQuote(to), dotQuote(dotLabel(target)))
+					}
+				} else if !dangling[to] {
+					dangling[to] = true
+					fmt.Fprintf(&b, "\t%s [label=%s, style=dashed, color=gray];\n", dotQuote(to), dotQuote(strings.TrimSpace(ref)))
+				}
+				edge := fmt.Sprintf("\t%s -> %s [label=%s];\n", dotQuote(proposal.Filename), dotQuote(to), dotQuote(reference.key))
+				if !seen[edge] {
+					seen[edge] = true
+					edges = append(edges, edge)
+				}
+			}
+		}
+	}
+	for _, edge := range edges {
+		b.WriteString(edge)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotLabel labels the node of proposal by its number, if it has one, and
+// its title.
+func dotLabel(proposal *Proposal) string {
+	if n, ok := proposal.Number(); ok {
+		return fmt.Sprintf("KEP-%d\n%s", n, proposal.Title)
+	}
+	return proposal.Title
+}
+
+// dotQuote returns s as a quoted DOT identifier. Newlines become line breaks
+// in labels.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
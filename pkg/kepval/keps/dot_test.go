@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestToDOT(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "apply", Filename: "sig-api-machinery/0006-apply.md", Replaces: []string{"KEP-15"}},
+		{Title: `dry "run"`, Filename: "sig-api-machinery/0015-dry-run.md", SupersededBy: []string{"0006-apply.md"}},
+		{Title: "metrics", Filename: "sig-instrumentation/20181106-metrics.md", SeeAlso: []string{"KEP-15", "n/a", " n/a"}},
+	}
+	var out strings.Builder
+	if err := proposals.ToDOT(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph keps {
+	"sig-api-machinery/0006-apply.md" [label="KEP-6\napply"];
+	"sig-api-machinery/0015-dry-run.md" [label="KEP-15\ndry \"run\""];
+	"sig-instrumentation/20181106-metrics.md" [label="metrics"];
+	"unresolved:n/a" [label="n/a", style=dashed, color=gray];
+	"sig-api-machinery/0006-apply.md" -> "sig-api-machinery/0015-dry-run.md" [label="replaces"];
+	"sig-api-machinery/0015-dry-run.md" -> "sig-api-machinery/0006-apply.md" [label="superseded-by"];
+	"sig-instrumentation/20181106-metrics.md" -> "sig-api-machinery/0015-dry-run.md" [label="see-also"];
+	"sig-instrumentation/20181106-metrics.md" -> "unresolved:n/a" [label="see-also"];
+}
+`
+	if out.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := proposals[2:].ToDOTIn(&out, proposals); err != nil {
+		t.Fatal(err)
+	}
+	expected = `digraph keps {
+	"sig-instrumentation/20181106-metrics.md" [label="metrics"];
+	"sig-api-machinery/0015-dry-run.md" [label="KEP-15\ndry \"run\"", color=gray];
+	"unresolved:n/a" [label="n/a", style=dashed, color=gray];
+	"sig-instrumentation/20181106-metrics.md" -> "sig-api-machinery/0015-dry-run.md" [label="see-also"];
+	"sig-instrumentation/20181106-metrics.md" -> "unresolved:n/a" [label="see-also"];
+}
+`
+	if out.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, out.String())
+	}
+}
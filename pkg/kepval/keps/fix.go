@@ -0,0 +1,407 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	reListItem = regexp.MustCompile(`^\s*-(?:\s+(.*))?$`)
+	reFlowList = regexp.MustCompile(`^\[(.*)\]\s*(#.*)?$`)
+)
+
+// RemoveOwningSIG removes the owning SIG of p from its participating SIGs.
+// contents is the text of the KEP file of p; the returned text only differs
+// from it in the participating-sigs list of the metadata, so the rest of the
+// file keeps its formatting. ok is false, and contents is returned as is, if
+// the list does not name the owning SIG or cannot be rewritten.
+func (p *Proposal) RemoveOwningSIG(contents []byte) (fixed []byte, ok bool) {
+	lines, ok := removeListEntry(strings.Split(string(contents), "\n"), "participating-sigs", p.OwningSIG)
+	if !ok {
+		return contents, false
+	}
+	var kept []string
+	for _, sig := range p.ParticipatingSIGs {
+		if sig != p.OwningSIG {
+			kept = append(kept, sig)
+		}
+	}
+	p.ParticipatingSIGs = kept
+	return []byte(strings.Join(lines, "\n")), true
+}
+
+// straightQuotes replaces the validations.SmartQuotes with straight quotes.
+var straightQuotes = strings.NewReplacer("“", `"`, "”", `"`, "„", `"`, "‟", `"`, "‘", "'", "’", "'", "‚", "'", "‛", "'")
+
+// StraightenQuotes replaces the typographic quotes in the metadata of p with
+// straight quotes. contents is the text of the KEP file of p; only its
+// frontmatter changes. ok is false, and contents is returned as is, if the
+// frontmatter has no typographic quotes or would no longer be valid YAML
+// with straight quotes, such as a quoted title with quotes inside.
+func (p *Proposal) StraightenQuotes(contents []byte) (fixed []byte, ok bool) {
+	lines := strings.Split(string(contents), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return contents, false
+	}
+	var metadata []string
+	for i := 1; i < len(lines) && strings.TrimRight(lines[i], "\r") != "---"; i++ {
+		if straight := straightQuotes.Replace(lines[i]); straight != lines[i] {
+			lines[i], ok = straight, true
+		}
+		metadata = append(metadata, lines[i])
+	}
+	if !ok {
+		return contents, false
+	}
+	// decoded again, since a value wrapped in typographic quotes is quoted
+	// once they are straight, and then loses them
+	var straight Proposal
+	if err := yaml.Unmarshal([]byte(strings.Join(metadata, "\n")), &straight); err != nil {
+		return contents, false
+	}
+	values, straightValues := p.metadataStrings(), straight.metadataStrings()
+	for i, s := range values {
+		if len(straightValues) != len(values) {
+			// keys in another case are only canonicalized by the Parser
+			*s.value = straightQuotes.Replace(*s.value)
+			continue
+		}
+		*s.value = *straightValues[i].value
+	}
+	return []byte(strings.Join(lines, "\n")), true
+}
+
+// removeListEntry removes the entries equal to value from the list of the
+// top level key in the frontmatter of lines. Both block lists and single
+// line flow lists such as [a, b] are supported. A list that ends up empty is
+// written as [].
+func removeListEntry(lines []string, key, value string) ([]string, bool) {
+	i, line, found := frontmatterKey(lines, key)
+	if !found {
+		return lines, false
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(line, key+":"))
+	if match := reFlowList.FindStringSubmatch(rest); match != nil {
+		var kept []string
+		removed := false
+		for _, item := range strings.Split(match[1], ",") {
+			switch item = strings.TrimSpace(item); {
+			case unquote(item) == value:
+				removed = true
+			case item != "":
+				kept = append(kept, item)
+			}
+		}
+		if !removed {
+			return lines, false
+		}
+		fixed := append([]string(nil), lines...)
+		fixed[i] = flowLine(key, kept, match[2]) + strings.TrimPrefix(lines[i], line)
+		return fixed, true
+	}
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return lines, false
+	}
+	fixed := append([]string(nil), lines[:i+1]...)
+	removed, remaining := false, 0
+	j := i + 1
+	for ; j < len(lines); j++ {
+		match := reListItem.FindStringSubmatch(strings.TrimRight(lines[j], "\r"))
+		if match == nil {
+			break
+		}
+		if unquote(match[1]) == value {
+			removed = true
+			continue
+		}
+		remaining++
+		fixed = append(fixed, lines[j])
+	}
+	if !removed {
+		return lines, false
+	}
+	if remaining == 0 {
+		fixed[i] = key + ": []" + strings.TrimPrefix(lines[i], line)
+	}
+	return append(fixed, lines[j:]...), true
+}
+
+// frontmatterKey returns the index of the line of lines that sets the top
+// level key in the frontmatter, along with that line without its line
+// ending.
+func frontmatterKey(lines []string, key string) (i int, line string, found bool) {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0, "", false
+	}
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if line == "---" {
+			break
+		}
+		if strings.HasPrefix(line, key+":") {
+			return i, line, true
+		}
+	}
+	return 0, "", false
+}
+
+// flowLine returns the line that sets key to the flow list of items,
+// followed by comment if it is not empty.
+func flowLine(key string, items []string, comment string) string {
+	line := key + ": [" + strings.Join(items, ", ") + "]"
+	if comment != "" {
+		line += " " + comment
+	}
+	return line
+}
+
+// listKeys are the metadata keys of the string lists modeled by Proposal,
+// such as authors.
+var listKeys = func() []string {
+	var keys []string
+	t := reflect.TypeOf(Proposal{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Type != reflect.TypeOf([]string(nil)) {
+			continue
+		}
+		switch key := strings.Split(field.Tag.Get("yaml"), ",")[0]; key {
+		case "-":
+		case "":
+			// like yaml, fall back to the lowercased field name
+			keys = append(keys, strings.ToLower(field.Name))
+		default:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}()
+
+// NormalizeLists rewrites the string lists of the frontmatter in contents,
+// the text of a KEP file, that are written as a single line flow list, such
+// as authors: ["@a", "@b"], as block lists with one entry per line. Empty
+// lists stay []. Nothing else in contents changes, and normalizing again
+// changes nothing. ok is false if no list was rewritten.
+func NormalizeLists(contents []byte) (fixed []byte, ok bool) {
+	lines := strings.Split(string(contents), "\n")
+	for _, key := range listKeys {
+		if normalized, rewritten := blockList(lines, key); rewritten {
+			lines, ok = normalized, true
+		}
+	}
+	if !ok {
+		return contents, false
+	}
+	return []byte(strings.Join(lines, "\n")), true
+}
+
+// blockList rewrites the list of the top level key in the frontmatter of
+// lines from a single line flow list to a block list.
+func blockList(lines []string, key string) ([]string, bool) {
+	i, line, found := frontmatterKey(lines, key)
+	if !found {
+		return lines, false
+	}
+	match := reFlowList.FindStringSubmatch(strings.TrimSpace(strings.TrimPrefix(line, key+":")))
+	if match == nil {
+		return lines, false
+	}
+	items := splitFlowItems(match[1])
+	if len(items) == 0 {
+		return lines, false
+	}
+	eol := strings.TrimPrefix(lines[i], line)
+	keyLine := key + ":"
+	if match[2] != "" {
+		keyLine += " " + match[2]
+	}
+	fixed := append([]string(nil), lines[:i]...)
+	fixed = append(fixed, keyLine+eol)
+	for _, item := range items {
+		fixed = append(fixed, "  - "+item+eol)
+	}
+	return append(fixed, lines[i+1:]...), true
+}
+
+// SortLists sorts the authors, reviewers, approvers and participating-sigs
+// lists of p alphabetically, ignoring case. contents is the text of the KEP
+// file of p; the returned text only differs from it in the order of the
+// entries of those lists, and sorting again changes nothing. Lists with
+// entries that are not plain scalars, such as the reviewers of a SIG, are
+// left alone. ok is false if no list was reordered.
+func (p *Proposal) SortLists(contents []byte) (fixed []byte, ok bool) {
+	lines := strings.Split(string(contents), "\n")
+	for _, list := range []struct {
+		key     string
+		entries *[]string
+	}{
+		{"authors", &p.Authors},
+		{"participating-sigs", &p.ParticipatingSIGs},
+		{"reviewers", &p.Reviewers},
+		{"approvers", &p.Approvers},
+	} {
+		sorted, reordered := sortList(lines, list.key)
+		if !reordered {
+			continue
+		}
+		lines, ok = sorted, true
+		sort.SliceStable(*list.entries, func(i, j int) bool {
+			return strings.ToLower((*list.entries)[i]) < strings.ToLower((*list.entries)[j])
+		})
+	}
+	if !ok {
+		return contents, false
+	}
+	return []byte(strings.Join(lines, "\n")), true
+}
+
+// sortList sorts the entries of the list of the top level key in the
+// frontmatter of lines, either a block list or a single line flow list.
+func sortList(lines []string, key string) ([]string, bool) {
+	i, line, found := frontmatterKey(lines, key)
+	if !found {
+		return lines, false
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(line, key+":"))
+	if match := reFlowList.FindStringSubmatch(rest); match != nil {
+		items := splitFlowItems(match[1])
+		if !sortEntries(items, func(item string) string { return item }) {
+			return lines, false
+		}
+		fixed := append([]string(nil), lines...)
+		fixed[i] = flowLine(key, items, match[2]) + strings.TrimPrefix(lines[i], line)
+		return fixed, true
+	}
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return lines, false
+	}
+	j := i + 1
+	for ; j < len(lines); j++ {
+		if !reListItem.MatchString(strings.TrimRight(lines[j], "\r")) {
+			break
+		}
+	}
+	if j < len(lines) && strings.HasPrefix(lines[j], " ") {
+		// an entry continues on the next lines
+		return lines, false
+	}
+	items := append([]string(nil), lines[i+1:j]...)
+	if !sortEntries(items, func(item string) string {
+		return reListItem.FindStringSubmatch(strings.TrimRight(item, "\r"))[1]
+	}) {
+		return lines, false
+	}
+	fixed := append([]string(nil), lines[:i+1]...)
+	fixed = append(fixed, items...)
+	return append(fixed, lines[j:]...), true
+}
+
+// sortEntries sorts items by the scalar that entry returns for each of them,
+// ignoring case. It returns false, leaving items as they are, if they are
+// already sorted or one of them is not a plain scalar.
+func sortEntries(items []string, entry func(string) string) bool {
+	keys := make(map[string]string, len(items))
+	for _, item := range items {
+		value := entry(item)
+		if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") || strings.Contains(unquote(value), ": ") || strings.HasSuffix(unquote(value), ":") {
+			return false
+		}
+		keys[item] = strings.ToLower(unquote(value))
+	}
+	less := func(i, j int) bool { return keys[items[i]] < keys[items[j]] }
+	if sort.SliceIsSorted(items, less) {
+		return false
+	}
+	sort.SliceStable(items, less)
+	return true
+}
+
+// splitFlowItems splits the inside of a flow list at the commas that are not
+// quoted, dropping empty items.
+func splitFlowItems(list string) []string {
+	var items []string
+	var quote rune
+	start := 0
+	add := func(item string) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	for i, r := range list {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			add(list[start:i])
+			start = i + 1
+		}
+	}
+	add(list[start:])
+	return items
+}
+
+// unquote returns a YAML scalar without its trailing comment and quotes.
+func unquote(scalar string) string {
+	if i := strings.Index(scalar, " #"); i >= 0 {
+		scalar = scalar[:i]
+	}
+	return strings.Trim(strings.TrimSpace(scalar), `"'`)
+}
+
+// CheckFix returns an error unless fixed, the text of the KEP file of kep
+// after fixes such as RemoveOwningSIG, parses to the same metadata as kep,
+// both encoded by Proposal.MarshalYAML. The fixes only edit single lines of
+// the file, so this catches an edit that changed more than it was meant
+// to. KEPs with a kep.yaml next to them are not checked, since their file
+// does not hold all of their metadata.
+func (p *Parser) CheckFix(kep *Proposal, fixed []byte) error {
+	if kep.fsys != nil {
+		if _, err := fs.Stat(kep.fsys, path.Join(kep.dir, MetadataFile)); err == nil {
+			return nil
+		}
+	}
+	reparsed := p.ParseBytes(fixed)
+	if reparsed.Error != nil {
+		return errors.Wrap(reparsed.Error, "the fixed KEP does not parse")
+	}
+	expected, err := yaml.Marshal(kep)
+	if err != nil {
+		return err
+	}
+	actual, err := yaml.Marshal(reparsed)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, actual) {
+		return errors.Errorf("the fixed KEP has the metadata\n%s\ninstead of\n%s", actual, expected)
+	}
+	return nil
+}
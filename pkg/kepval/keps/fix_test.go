@@ -0,0 +1,191 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestRemoveOwningSIG(t *testing.T) {
+	testcases := []struct {
+		name         string
+		metadata     string
+		expected     string
+		participants []string
+	}{
+		{
+			name:         "block list",
+			metadata:     "participating-sigs:\n  - sig-node\n  - \"sig-apps\" # owner\n  - sig-cli\nreviewers:\n  - \"@a\"\n",
+			expected:     "participating-sigs:\n  - sig-node\n  - sig-cli\nreviewers:\n  - \"@a\"\n",
+			participants: []string{"sig-node", "sig-cli"},
+		},
+		{
+			name:     "only entry",
+			metadata: "participating-sigs:\n- sig-apps\nstatus: provisional\n",
+			expected: "participating-sigs: []\nstatus: provisional\n",
+		},
+		{
+			name:         "flow list",
+			metadata:     "participating-sigs: [sig-apps, 'sig-node'] # sigs\n",
+			expected:     "participating-sigs: ['sig-node'] # sigs\n",
+			participants: []string{"sig-node"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			contents := "---\ntitle: test\nowning-sig: sig-apps\n" + tc.metadata + "---\n\n- sig-apps\n"
+			p := &keps.Parser{}
+			kep := p.Parse(strings.NewReader(contents))
+			if kep.Error != nil {
+				t.Fatal(kep.Error)
+			}
+			fixed, ok := kep.RemoveOwningSIG([]byte(contents))
+			if !ok {
+				t.Fatal("expected the owning SIG to be removed")
+			}
+			expected := "---\ntitle: test\nowning-sig: sig-apps\n" + tc.expected + "---\n\n- sig-apps\n"
+			if string(fixed) != expected {
+				t.Fatalf("expected\n%s\nbut got\n%s", expected, fixed)
+			}
+			if !reflect.DeepEqual(kep.ParticipatingSIGs, tc.participants) {
+				t.Fatalf("expected participating SIGs %v but got %v", tc.participants, kep.ParticipatingSIGs)
+			}
+			refixed := p.Parse(strings.NewReader(string(fixed)))
+			if strings.Join(refixed.ParticipatingSIGs, ",") != strings.Join(tc.participants, ",") {
+				t.Fatalf("expected the fixed file to list %v but got %v", tc.participants, refixed.ParticipatingSIGs)
+			}
+		})
+	}
+}
+
+func TestRemoveOwningSIGUnchanged(t *testing.T) {
+	contents := "---\ntitle: test\nowning-sig: sig-apps\nparticipating-sigs:\n  - sig-node\n---\n"
+	kep := (&keps.Parser{}).Parse(strings.NewReader(contents))
+	if fixed, ok := kep.RemoveOwningSIG([]byte(contents)); ok || string(fixed) != contents {
+		t.Fatalf("expected the file to be left as is but got %q", fixed)
+	}
+}
+
+func TestValidateParticipatingSIGs(t *testing.T) {
+	defer func() { keps.Strict = false }()
+	p := validProposal()
+	p.ParticipatingSIGs = []string{"sig-node", p.OwningSIG}
+	if errs := p.Validate(); len(errs) != 0 {
+		t.Fatalf("expected no errors without Strict but got %v", errs)
+	}
+	keps.Strict = true
+	errs := p.Validate()
+	if len(errs) != 1 || keps.IsWarning(errs[0]) {
+		t.Fatalf("expected one error but got %v", errs)
+	}
+}
+
+func TestNormalizeLists(t *testing.T) {
+	contents := "---\ntitle: [not, a, list]\nauthors: [\"@a\", '@b, c']  # people\nowning-sig: sig-apps\nparticipating-sigs: []\nreviewers:\n  - \"@c\"\nsee-also: [KEP-15]\n---\n\nreplaces: [a, b]\n"
+	expected := "---\ntitle: [not, a, list]\nauthors: # people\n  - \"@a\"\n  - '@b, c'\nowning-sig: sig-apps\nparticipating-sigs: []\nreviewers:\n  - \"@c\"\nsee-also:\n  - KEP-15\n---\n\nreplaces: [a, b]\n"
+	fixed, ok := keps.NormalizeLists([]byte(contents))
+	if !ok || string(fixed) != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, fixed)
+	}
+	if again, ok := keps.NormalizeLists(fixed); ok || string(again) != expected {
+		t.Fatalf("expected normalizing twice to change nothing but got\n%s", again)
+	}
+}
+
+func TestSortLists(t *testing.T) {
+	contents := "---\ntitle: test\nauthors: [\"@c\", \"@A\", \"@b\"]  # people\nowning-sig: sig-apps\nparticipating-sigs:\n  - sig-node\n  - sig-auth\nreviewers:\n  - sig-node:\n    - \"@z\"\n    - \"@y\"\napprovers:\n  - \"@a\"\n---\n\nreplaces: [b, a]\n"
+	expected := "---\ntitle: test\nauthors: [\"@A\", \"@b\", \"@c\"] # people\nowning-sig: sig-apps\nparticipating-sigs:\n  - sig-auth\n  - sig-node\nreviewers:\n  - sig-node:\n    - \"@z\"\n    - \"@y\"\napprovers:\n  - \"@a\"\n---\n\nreplaces: [b, a]\n"
+	kep := (&keps.Parser{}).Parse(strings.NewReader(contents))
+	fixed, ok := kep.SortLists([]byte(contents))
+	if !ok || string(fixed) != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, fixed)
+	}
+	if sigs := []string{"sig-auth", "sig-node"}; !reflect.DeepEqual(kep.ParticipatingSIGs, sigs) {
+		t.Errorf("expected participating SIGs %v but got %v", sigs, kep.ParticipatingSIGs)
+	}
+	if again, ok := kep.SortLists(fixed); ok || string(again) != expected {
+		t.Fatalf("expected sorting twice to change nothing but got\n%s", again)
+	}
+}
+
+func TestStraightenQuotes(t *testing.T) {
+	contents := "---\ntitle: The “best” KEP’s title\nowning-sig: sig-apps\n---\n\nBody “quotes” stay.\n"
+	expected := "---\ntitle: The \"best\" KEP's title\nowning-sig: sig-apps\n---\n\nBody “quotes” stay.\n"
+	kep := (&keps.Parser{}).Parse(strings.NewReader(contents))
+	fixed, ok := kep.StraightenQuotes([]byte(contents))
+	if !ok || string(fixed) != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, fixed)
+	}
+	if title := `The "best" KEP's title`; kep.Title != title {
+		t.Errorf("expected title %q but got %q", title, kep.Title)
+	}
+	if again, ok := kep.StraightenQuotes(fixed); ok || string(again) != expected {
+		t.Fatalf("expected straightening twice to change nothing but got\n%s", again)
+	}
+
+	// the quotes around a reviewer become YAML quotes
+	contents = "---\ntitle: test\nowning-sig: sig-apps\nreviewers:\n  - “@someone”\n---\n"
+	kep = (&keps.Parser{}).Parse(strings.NewReader(contents))
+	if _, ok := kep.StraightenQuotes([]byte(contents)); !ok || len(kep.Reviewers) != 1 || kep.Reviewers[0] != "@someone" {
+		t.Errorf("expected the reviewer @someone but got %q", kep.Reviewers)
+	}
+
+	// straight quotes would end the quoted title early
+	contents = "---\ntitle: \"The “best” title\"\nowning-sig: sig-apps\n---\n"
+	kep = (&keps.Parser{}).Parse(strings.NewReader(contents))
+	if fixed, ok := kep.StraightenQuotes([]byte(contents)); ok || string(fixed) != contents {
+		t.Fatalf("expected the file to be left as is but got %q", fixed)
+	}
+}
+
+func TestValidateQuotes(t *testing.T) {
+	p := validProposal()
+	p.Title = "The “best” title"
+	p.Authors = append(p.Authors, "‘@someone’")
+	errs := p.Validate()
+	if len(errs) != 2 {
+		t.Fatalf("expected two warnings but got %v", errs)
+	}
+	for _, err := range errs {
+		if !keps.IsWarning(err) {
+			t.Errorf("expected a warning but got %v", err)
+		}
+	}
+}
+
+func TestCheckFix(t *testing.T) {
+	contents := "---\ntitle: test\nowning-sig: sig-apps\nparticipating-sigs: [sig-apps, sig-node]\n---\n"
+	p := &keps.Parser{}
+	kep := p.Parse(strings.NewReader(contents))
+	fixed, ok := kep.RemoveOwningSIG([]byte(contents))
+	if !ok {
+		t.Fatal("expected the owning SIG to be removed")
+	}
+	if err := p.CheckFix(kep, fixed); err != nil {
+		t.Fatalf("expected the fix to be accepted but got %v", err)
+	}
+	if err := p.CheckFix(kep, []byte(contents)); err == nil {
+		t.Error("expected the unfixed file to be rejected")
+	}
+	if err := p.CheckFix(kep, []byte("---\ntitle: test\n")); err == nil {
+		t.Error("expected a file that does not parse to be rejected")
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"html/template"
+	"io"
+	"sort"
+)
+
+// htmlReport is a standalone page, with its styles and script inline so it
+// works offline, that lists the KEPs in a table that can be filtered.
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Kubernetes Enhancement Proposals</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+input { margin-bottom: 1em; padding: 0.3em; width: 30em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #eee; }
+tr.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1>Kubernetes Enhancement Proposals</h1>
+<input id="filter" type="search" placeholder="Filter by title, SIG, status or milestone">
+<table>
+<thead>
+<tr><th>Title</th><th>SIG</th><th>Status</th><th>Milestone</th></tr>
+</thead>
+<tbody id="keps">
+{{- range .}}
+<tr><td>{{if .Filename}}<a href="{{.Filename}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</td><td>{{.OwningSIG}}</td><td>{{.Status}}</td><td>{{.LatestMilestone}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+document.getElementById("filter").addEventListener("input", function (event) {
+  var query = event.target.value.toLowerCase();
+  var rows = document.getElementById("keps").rows;
+  for (var i = 0; i < rows.length; i++) {
+    rows[i].className = rows[i].textContent.toLowerCase().indexOf(query) === -1 ? "hidden" : "";
+  }
+});
+</script>
+</body>
+</html>
+`))
+
+// ToHTML writes the proposals to w as a standalone HTML page with a table
+// that can be filtered, sorted by owning SIG and then title. The title of
+// each proposal links to its Filename, if it has one. Every field is
+// escaped.
+func (p Proposals) ToHTML(w io.Writer) error {
+	rows := make(Proposals, len(p))
+	copy(rows, p)
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].OwningSIG != rows[j].OwningSIG {
+			return rows[i].OwningSIG < rows[j].OwningSIG
+		}
+		return rows[i].Title < rows[j].Title
+	})
+	return htmlReport.Execute(w, rows)
+}
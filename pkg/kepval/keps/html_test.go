@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestToHTML(t *testing.T) {
+	proposals := keps.Proposals{
+		{
+			Title:           "Dry run",
+			OwningSIG:       "sig-api-machinery",
+			Status:          "implemented",
+			LatestMilestone: "v1.18",
+			Filename:        "https://github.com/kubernetes/enhancements/blob/master/keps/sig-api-machinery/0015-dry-run.md",
+		},
+		{
+			Title:     "Server Side Apply",
+			OwningSIG: "sig-api-machinery",
+			Status:    "implementable",
+			Filename:  "sig-api-machinery/0006-apply.md",
+		},
+		{
+			Title:     `<script>alert("x")</script> & more`,
+			OwningSIG: "sig-apps",
+			Status:    "provisional",
+			Filename:  "javascript:alert(1)",
+		},
+		{
+			Title:     "Unlinked",
+			OwningSIG: "sig-apps",
+			Status:    "provisional",
+		},
+	}
+	var out bytes.Buffer
+	if err := proposals.ToHTML(&out); err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile(filepath.Join("testdata", "proposals.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != string(golden) {
+		t.Fatalf("output does not match testdata/proposals.html, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import "strings"
+
+// DefaultIgnored returns the names of the markdown files in the KEP
+// directory that are not KEPs, such as the template and the README.
+func DefaultIgnored() []string {
+	return []string{
+		"0023-documentation-for-images.md",
+		"0004-cloud-provider-template.md",
+		"0001a-meta-kep-implementation.md",
+		"0001-kubernetes-enhancement-proposal-process.md",
+		"YYYYMMDD-kep-template.md",
+		"README.md",
+		"kep-faq.md",
+	}
+}
+
+// Ignored reports whether the file with the base name name is not a KEP:
+// files that are not markdown, the DefaultIgnored files and the files named
+// in extra.
+func Ignored(name string, extra ...string) bool {
+	if !strings.HasSuffix(name, "md") {
+		return true
+	}
+	for _, ignored := range append(DefaultIgnored(), extra...) {
+		if name == ignored {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestIgnored(t *testing.T) {
+	for _, name := range keps.DefaultIgnored() {
+		if !keps.Ignored(name) {
+			t.Errorf("expected %s to be ignored", name)
+		}
+	}
+	testcases := []struct {
+		name    string
+		extra   []string
+		ignored bool
+	}{
+		{"0015-dry-run.md", nil, false},
+		{"OWNERS", nil, true},
+		{"kep.yaml", nil, true},
+		{"0015-dry-run.md", []string{"0015-dry-run.md"}, true},
+		{"0026-ttl-after-finish.md", []string{"0015-dry-run.md"}, false},
+	}
+	for _, tc := range testcases {
+		if ignored := keps.Ignored(tc.name, tc.extra...); ignored != tc.ignored {
+			t.Errorf("Ignored(%q, %v): expected %v but got %v", tc.name, tc.extra, tc.ignored, ignored)
+		}
+	}
+}
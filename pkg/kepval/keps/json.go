@@ -0,0 +1,301 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/enhancements/pkg/kepval/keps/validations"
+)
+
+// jsonField is a key of a proposal in the json output along with its
+// already encoded value.
+type jsonField struct {
+	key   string
+	value string
+}
+
+// jsonFields returns the fields written for p in the json output, in the
+// order they are written. The path is only written when Filename is set and
+// the markdown body is left out once DropBody has been called.
+func (p *Proposal) jsonFields() []jsonField {
+	fields := []jsonField{
+		{"title", quote(p.Title)},
+		{"owning-sig", quote(p.OwningSIG)},
+		{"participating-sigs", quoteList(p.ParticipatingSIGs)},
+		{"reviewers", quoteList(p.Reviewers)},
+		{"authors", quoteList(p.Authors)},
+		{"editor", quote(p.Editor)},
+		{"creation-date", quote(p.CreationDate)},
+		{"last-updated", quote(p.LastUpdated)},
+		{"status", quote(p.Status)},
+		{"see-also", quoteList(p.SeeAlso)},
+		{"replaces", quoteList(p.Replaces)},
+		{"superseded-by", quoteList(p.SupersededBy)},
+		{"tracking-issue", quote(p.TrackingIssue)},
+	}
+	if p.Filename != "" {
+		fields = append(fields, jsonField{"path", quote(p.Filename)})
+	}
+	if !p.bodyDropped {
+		contents, _ := json.Marshal(p.Contents)
+		fields = append(fields, jsonField{"markdown", string(contents)})
+	}
+	return fields
+}
+
+// Hash identifies the proposal in the json output. It is derived from the
+// owning SIG and the title.
+func (p *Proposal) Hash() string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(p.OwningSIG+":"+p.Title)))
+}
+
+// ValidateUniqueHashes reports every proposal whose Hash is the same as the
+// Hash of an earlier proposal, naming both files. Such a proposal would
+// overwrite the earlier one in the output of JSONBytes.
+func (p Proposals) ValidateUniqueHashes() []error {
+	return p.ValidateUniqueHashesIn(p)
+}
+
+// ValidateUniqueHashesIn is like ValidateUniqueHashes for tree, which holds
+// p along with the other KEPs, but only reports the collisions that one of
+// p is part of.
+func (p Proposals) ValidateUniqueHashesIn(tree Proposals) []error {
+	selected := p.set()
+	var errs []error
+	seen := map[string]*Proposal{}
+	for _, proposal := range tree {
+		hash := proposal.Hash()
+		first, earlier := seen[hash], ""
+		if first != nil {
+			earlier = first.Filename
+		}
+		if err := validations.ValidateUniqueHash(hash, earlier, proposal.Filename); err != nil {
+			if selected[proposal] || selected[first] {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		seen[hash] = proposal
+	}
+	return errs
+}
+
+// ValidateOwningSIGs reports every proposal whose owning SIG is empty or not
+// well formed. Hash would not tell such a proposal apart from one with the
+// same title in another SIG.
+func (p Proposals) ValidateOwningSIGs() []error {
+	var errs []error
+	for _, proposal := range p {
+		if err := validations.ValidateOwningSIGFormat(proposal.Filename, proposal.OwningSIG); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// MarshalJSON encodes the proposal as a single line json object with the
+// fields in the same order as JSONBytes.
+func (p *Proposal) MarshalJSON() ([]byte, error) {
+	fields := p.jsonFields()
+	pairs := make([]string, len(fields))
+	for i, field := range fields {
+		pairs[i] = quote(field.key) + ":" + field.value
+	}
+	return []byte("{" + strings.Join(pairs, ",") + "}"), nil
+}
+
+// UnmarshalJSON decodes a proposal written by MarshalJSON or JSONBytes. A
+// proposal written without its markdown body is decoded as if DropBody had
+// been called.
+func (p *Proposal) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Title             string   `json:"title"`
+		OwningSIG         string   `json:"owning-sig"`
+		ParticipatingSIGs []string `json:"participating-sigs"`
+		Reviewers         []string `json:"reviewers"`
+		Authors           []string `json:"authors"`
+		Editor            string   `json:"editor"`
+		CreationDate      string   `json:"creation-date"`
+		LastUpdated       string   `json:"last-updated"`
+		Status            string   `json:"status"`
+		SeeAlso           []string `json:"see-also"`
+		Replaces          []string `json:"replaces"`
+		SupersededBy      []string `json:"superseded-by"`
+		TrackingIssue     string   `json:"tracking-issue"`
+		Path              string   `json:"path"`
+		Markdown          *string  `json:"markdown"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*p = Proposal{
+		Title:             fields.Title,
+		OwningSIG:         fields.OwningSIG,
+		ParticipatingSIGs: fields.ParticipatingSIGs,
+		Reviewers:         fields.Reviewers,
+		Authors:           fields.Authors,
+		Editor:            fields.Editor,
+		CreationDate:      fields.CreationDate,
+		LastUpdated:       fields.LastUpdated,
+		Status:            fields.Status,
+		SeeAlso:           fields.SeeAlso,
+		Replaces:          fields.Replaces,
+		SupersededBy:      fields.SupersededBy,
+		TrackingIssue:     fields.TrackingIssue,
+		Filename:          fields.Path,
+	}
+	if fields.Markdown == nil {
+		p.bodyDropped = true
+	} else {
+		p.Contents = *fields.Markdown
+	}
+	return nil
+}
+
+// ReadJSON decodes the proposals that JSONBytes wrote to r, in the order
+// they were written. It fails if a proposal is not keyed by its Hash, since
+// the output was then written by a kepify that hashed proposals
+// differently and cannot be merged with this one's.
+func ReadJSON(r io.Reader) (Proposals, error) {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('{') {
+		return nil, errors.New("expected a json object of proposals")
+	}
+	var proposals Proposals
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		if key == "kepify" {
+			var info json.RawMessage
+			if err := decoder.Decode(&info); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		proposal := &Proposal{}
+		if err := decoder.Decode(proposal); err != nil {
+			return nil, errors.Wrapf(err, "error decoding %s", key)
+		}
+		if hash := proposal.Hash(); hash != key {
+			return nil, errors.Errorf("%q is keyed by %s but its hash is %s, it was written with an incompatible hash", proposal.Title, key, hash)
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals, nil
+}
+
+// Merge returns p with each proposal that has the same Hash as one in fresh
+// replaced by the one in fresh, followed by the other proposals of fresh in
+// their order.
+func (p Proposals) Merge(fresh Proposals) Proposals {
+	byHash := make(map[string]*Proposal, len(fresh))
+	for _, proposal := range fresh {
+		byHash[proposal.Hash()] = proposal
+	}
+	merged := make(Proposals, 0, len(p)+len(fresh))
+	for _, proposal := range p {
+		if replacement, ok := byHash[proposal.Hash()]; ok {
+			proposal = replacement
+			delete(byHash, proposal.Hash())
+		}
+		merged = append(merged, proposal)
+	}
+	for _, proposal := range fresh {
+		if _, ok := byHash[proposal.Hash()]; ok {
+			merged = append(merged, proposal)
+			delete(byHash, proposal.Hash())
+		}
+	}
+	return merged
+}
+
+// JSONBytes returns the proposals as a json object keyed by the Hash of
+// each proposal, in the order of p. The "kepify" key, which comes first,
+// records the build that wrote it, see BuildInfo.
+func (p Proposals) JSONBytes() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	separator := ","
+	if len(p) == 0 {
+		separator = ""
+	}
+	info, err := json.Marshal(map[string]string{
+		"version": Version,
+		"commit":  Commit,
+		"date":    Date,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&buf, "\t\"kepify\": %s%s\n", info, separator)
+	for i, proposal := range p {
+		fmt.Fprintf(&buf, "\t%s: {\n", quote(proposal.Hash()))
+		fields := proposal.jsonFields()
+		for j, field := range fields {
+			separator := ","
+			if j == len(fields)-1 {
+				separator = ""
+			}
+			fmt.Fprintf(&buf, "\t\t%s: %s%s\n", quote(field.key), field.value, separator)
+		}
+		if i < len(p)-1 {
+			buf.WriteString("\t},\n")
+		} else {
+			buf.WriteString("\t}\n")
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// WriteJSON writes the output of JSONBytes to w.
+func (p Proposals) WriteJSON(w io.Writer) error {
+	out, err := p.JSONBytes()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// quote encodes s as a json string. Unlike json.Marshal it leaves
+// characters such as & as they are.
+func quote(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// quoteList encodes values as a json array, null when it is nil.
+func quoteList(values []string) string {
+	contents, _ := json.Marshal(values)
+	return string(contents)
+}
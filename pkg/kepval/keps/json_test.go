@@ -0,0 +1,203 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestJSONBytes(t *testing.T) {
+	defer func(version, commit, date string) {
+		keps.Version, keps.Commit, keps.Date = version, commit, date
+	}(keps.Version, keps.Commit, keps.Date)
+	keps.Version, keps.Commit, keps.Date = "v0.1.0", "abc123", "2020-01-01"
+
+	dropped := &keps.Proposal{
+		Title:     "Dry run",
+		Authors:   []string{"@apelisse"},
+		OwningSIG: "sig-api-machinery",
+		Status:    "implemented",
+		Contents:  "# Dry run\n",
+	}
+	dropped.DropBody()
+	proposals := keps.Proposals{
+		{
+			Title:             "Server Side Apply",
+			Authors:           []string{"@jennybuckley", "@apelisse"},
+			OwningSIG:         "sig-api-machinery",
+			ParticipatingSIGs: []string{"sig-cli"},
+			Reviewers:         []string{"@lavalamp"},
+			CreationDate:      "2018-03-28",
+			LastUpdated:       "2019-07-12",
+			Status:            "implementable",
+			TrackingIssue:     "555",
+			Filename:          "sig-api-machinery/0006-apply.md",
+			Contents:          "# Server Side Apply\n\nApply <& merge>\n",
+		},
+		dropped,
+	}
+	out, err := proposals.JSONBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile(filepath.Join("testdata", "proposals.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(golden) {
+		t.Fatalf("output does not match testdata/proposals.json, got:\n%s", out)
+	}
+
+	var written bytes.Buffer
+	if err := proposals.WriteJSON(&written); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written.Bytes(), out) {
+		t.Fatalf("expected WriteJSON to write the output of JSONBytes but got:\n%s", written.String())
+	}
+
+	for _, proposal := range proposals {
+		line, err := json.Marshal(proposal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(line, &fields); err != nil {
+			t.Fatalf("expected valid json but got %v:\n%s", err, line)
+		}
+		if _, ok := fields["markdown"]; ok == (proposal == dropped) {
+			t.Errorf("%s: unexpected markdown field in %s", proposal.Title, line)
+		}
+	}
+
+	out, err = keps.Proposals{}.JSONBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out, &map[string]interface{}{}); err != nil {
+		t.Fatalf("expected valid json for no proposals but got %v:\n%s", err, out)
+	}
+}
+
+func TestValidateUniqueHashes(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "Dry run", OwningSIG: "sig-api-machinery", Filename: "sig-api-machinery/0015-dry-run.md"},
+		{Title: "Dry run", OwningSIG: "sig-cli", Filename: "sig-cli/0015-dry-run.md"},
+		{Title: "Dry run", OwningSIG: "sig-api-machinery", Filename: "sig-api-machinery/0016-dry-run.md"},
+	}
+	errs := proposals.ValidateUniqueHashes()
+	if len(errs) != 1 {
+		t.Fatalf("expected one collision but got %v", errs)
+	}
+	expected := "sig-api-machinery/0015-dry-run.md and sig-api-machinery/0016-dry-run.md have the same owning-sig and title, so both would be written as " + proposals[0].Hash()
+	if errs[0].Error() != expected {
+		t.Fatalf("expected %q but got %q", expected, errs[0])
+	}
+	if errs := proposals[:2].ValidateUniqueHashes(); len(errs) != 0 {
+		t.Fatalf("did not expect a collision: %v", errs)
+	}
+	if errs := proposals[2:].ValidateUniqueHashesIn(proposals); len(errs) != 1 || errs[0].Error() != expected {
+		t.Fatalf("expected the collision with the rest of the tree but got %v", errs)
+	}
+	if errs := proposals[1:2].ValidateUniqueHashesIn(proposals); len(errs) != 0 {
+		t.Fatalf("expected the collisions of other KEPs to be left out but got %v", errs)
+	}
+}
+
+func TestValidateOwningSIGs(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "Dry run", OwningSIG: "sig-api-machinery", Filename: "sig-api-machinery/0015-dry-run.md"},
+		{Title: "Dry run", Filename: "sig-cli/0015-dry-run.md"},
+		{Title: "Apply", OwningSIG: "SIG API:Machinery", Filename: "sig-api-machinery/0006-apply.md"},
+	}
+	errs := proposals.ValidateOwningSIGs()
+	expected := []string{
+		"sig-cli/0015-dry-run.md has no owning-sig, so its output key would collide with KEPs of the same title in other SIGs",
+		`sig-api-machinery/0006-apply.md has the owning-sig "SIG API:Machinery", which must be lowercase words joined by dashes, such as sig-node`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors but got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("expected %q but got %q", expected[i], err)
+		}
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	golden, err := os.ReadFile(filepath.Join("testdata", "proposals.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposals, err := keps.ReadJSON(bytes.NewReader(golden))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals) != 2 || proposals[0].Title != "Server Side Apply" || proposals[1].Title != "Dry run" {
+		t.Fatalf("expected the proposals in the order they were written but got %v", proposals)
+	}
+	if path := "sig-api-machinery/0006-apply.md"; proposals[0].Filename != path {
+		t.Errorf("expected path %q but got %q", path, proposals[0].Filename)
+	}
+
+	defer func(version, commit, date string) {
+		keps.Version, keps.Commit, keps.Date = version, commit, date
+	}(keps.Version, keps.Commit, keps.Date)
+	keps.Version, keps.Commit, keps.Date = "v0.1.0", "abc123", "2020-01-01"
+	out, err := proposals.JSONBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(golden) {
+		t.Fatalf("expected reading and writing to keep testdata/proposals.json as is but got:\n%s", out)
+	}
+}
+
+func TestReadJSONIncompatibleHash(t *testing.T) {
+	in := `{"kepify": {}, "0123": {"title": "test", "owning-sig": "sig-node"}}`
+	if _, err := keps.ReadJSON(bytes.NewReader([]byte(in))); err == nil {
+		t.Fatal("expected an error for a proposal that is not keyed by its hash")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	existing := keps.Proposals{
+		{Title: "a", OwningSIG: "sig-node", Status: "provisional"},
+		{Title: "b", OwningSIG: "sig-node", Status: "provisional"},
+	}
+	fresh := keps.Proposals{
+		{Title: "c", OwningSIG: "sig-node", Status: "provisional"},
+		{Title: "a", OwningSIG: "sig-node", Status: "implementable"},
+	}
+	merged := existing.Merge(fresh)
+	var got []string
+	for _, proposal := range merged {
+		got = append(got, proposal.Title+":"+proposal.Status)
+	}
+	expected := []string{"a:implementable", "b:provisional", "c:provisional"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v but got %v", expected, got)
+	}
+}
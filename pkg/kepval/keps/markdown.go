@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Field returns the value of the metadata field with the given key, such as
+// "owning-sig", formatted for display. Lists are joined with commas and
+// "path" is the Filename of the proposal. "summary" is the Summary section
+// of the body with its links stripped. ok is false for unknown keys.
+func (p *Proposal) Field(key string) (value string, ok bool) {
+	switch key {
+	case "title":
+		return p.Title, true
+	case "authors":
+		return strings.Join(p.Authors, ", "), true
+	case "owning-sig":
+		return p.OwningSIG, true
+	case "participating-sigs":
+		return strings.Join(p.ParticipatingSIGs, ", "), true
+	case "reviewers":
+		return strings.Join(p.Reviewers, ", "), true
+	case "approvers":
+		return strings.Join(p.Approvers, ", "), true
+	case "editor":
+		return p.Editor, true
+	case "creation-date":
+		return p.CreationDate, true
+	case "last-updated":
+		return p.LastUpdated, true
+	case "status":
+		return p.Status, true
+	case "see-also":
+		return strings.Join(p.SeeAlso, ", "), true
+	case "replaces":
+		return strings.Join(p.Replaces, ", "), true
+	case "superseded-by":
+		return strings.Join(p.SupersededBy, ", "), true
+	case "tracking-issue":
+		return p.TrackingIssue, true
+	case "path":
+		return p.Filename, true
+	case "summary":
+		return StripLinks(p.Summary()), true
+	}
+	return "", false
+}
+
+// ToMarkdownTable writes the proposals to w as a markdown table with one
+// column for each of the given Field keys. Rows are sorted by the sortBy
+// column, or left in their current order if sortBy is empty.
+func (p Proposals) ToMarkdownTable(w io.Writer, columns []string, sortBy string) error {
+	for _, column := range append([]string{sortBy}, columns...) {
+		if _, ok := (&Proposal{}).Field(column); column != "" && !ok {
+			return errors.Errorf("unknown column %q", column)
+		}
+	}
+	rows := make(Proposals, len(p))
+	copy(rows, p)
+	if sortBy != "" {
+		sort.SliceStable(rows, func(i, j int) bool {
+			a, _ := rows[i].Field(sortBy)
+			b, _ := rows[j].Field(sortBy)
+			return a < b
+		})
+	}
+
+	separators := make([]string, len(columns))
+	for i := range columns {
+		separators[i] = "---"
+	}
+	if _, err := fmt.Fprintf(w, "| %s |\n| %s |\n", strings.Join(columns, " | "), strings.Join(separators, " | ")); err != nil {
+		return err
+	}
+	for _, proposal := range rows {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			value, _ := proposal.Field(column)
+			cells[i] = escapeCell(value)
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeCell makes value safe to use in a markdown table cell.
+func escapeCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.Join(strings.Fields(value), " ")
+}
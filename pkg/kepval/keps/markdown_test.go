@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestToMarkdownTable(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "b | pipes", OwningSIG: "sig-node", Status: "provisional", Authors: []string{"@a", "@b"}},
+		{Title: "a", OwningSIG: "sig-apps", Status: "implementable", Contents: "## Summary\n\nSee the\n[design](design.md).\n"},
+	}
+	testcases := []struct {
+		name     string
+		columns  []string
+		sortBy   string
+		expected string
+	}{
+		{
+			name:    "current order",
+			columns: []string{"title", "status"},
+			expected: `| title | status |
+| --- | --- |
+| b \| pipes | provisional |
+| a | implementable |
+`,
+		},
+		{
+			name:    "sorted with lists",
+			columns: []string{"owning-sig", "authors"},
+			sortBy:  "title",
+			expected: `| owning-sig | authors |
+| --- | --- |
+| sig-apps |  |
+| sig-node | @a, @b |
+`,
+		},
+		{
+			name:    "summary",
+			columns: []string{"title", "summary"},
+			expected: `| title | summary |
+| --- | --- |
+| b \| pipes |  |
+| a | See the design. |
+`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := proposals.ToMarkdownTable(&buf, tc.columns, tc.sortBy); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tc.expected {
+				t.Fatalf("expected\n%s\nbut got\n%s", tc.expected, buf.String())
+			}
+		})
+	}
+
+	if err := proposals.ToMarkdownTable(&bytes.Buffer{}, []string{"nope"}, ""); err == nil {
+		t.Fatal("expected an error for an unknown column")
+	}
+}
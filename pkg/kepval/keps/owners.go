@@ -0,0 +1,52 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Owners are the approvers and reviewers listed in an OWNERS file.
+type Owners struct {
+	Approvers []string `yaml:"approvers"`
+	Reviewers []string `yaml:"reviewers"`
+	// Source names the OWNERS file in messages.
+	Source string `yaml:"-"`
+}
+
+// ParseOwners reads an OWNERS file, naming it source in messages. Keys
+// other than approvers and reviewers, such as labels, are ignored, and
+// aliases from OWNERS_ALIASES are not expanded.
+func ParseOwners(r io.Reader, source string) (*Owners, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	owners := &Owners{Source: source}
+	if err := yaml.Unmarshal(data, owners); err != nil {
+		return nil, errors.Wrapf(err, "error unmarshaling %s", source)
+	}
+	return owners, nil
+}
+
+// Handles returns the approvers followed by the reviewers.
+func (o *Owners) Handles() []string {
+	return append(append([]string(nil), o.Approvers...), o.Reviewers...)
+}
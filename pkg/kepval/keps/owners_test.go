@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+const ownersFile = `# See the OWNERS docs at https://go.k8s.io/owners
+
+reviewers:
+  - deads2k
+  - jpbetz
+approvers:
+  - lavalamp
+labels:
+  - sig/api-machinery
+`
+
+func TestParseOwners(t *testing.T) {
+	owners, err := keps.ParseOwners(strings.NewReader(ownersFile), "OWNERS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handles := strings.Join(owners.Handles(), ","); handles != "lavalamp,deads2k,jpbetz" {
+		t.Fatalf("unexpected handles %q", handles)
+	}
+	if _, err := keps.ParseOwners(strings.NewReader("approvers: [\n"), "broken/OWNERS"); err == nil || !strings.Contains(err.Error(), "broken/OWNERS") {
+		t.Fatalf("expected an error naming the file but got %v", err)
+	}
+}
+
+func TestValidateOwners(t *testing.T) {
+	defer func(owners *keps.Owners) { keps.RequiredOwners = owners }(keps.RequiredOwners)
+	owners, err := keps.ParseOwners(strings.NewReader(ownersFile), "OWNERS")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name     string
+		owners   *keps.Owners
+		editor   string
+		expected []string
+	}{
+		{
+			name:     "unknown handles",
+			owners:   owners,
+			editor:   "@sttts",
+			expected: []string{`"authors" lists @jpbetz2`, `"editor" lists @sttts`},
+		},
+		{
+			name:   "editor left as TBD",
+			owners: owners,
+			editor: "TBD",
+			expected: []string{
+				`"authors" lists @jpbetz2, who is not an approver or reviewer in OWNERS`,
+			},
+		},
+		{
+			name:   "check is off without owners",
+			editor: "@sttts",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			keps.RequiredOwners = tc.owners
+			p := validProposal()
+			p.Authors = []string{"@JPBetz", "@jpbetz2"}
+			p.Reviewers = []string{"deads2k"}
+			p.Approvers = []string{"@lavalamp"}
+			p.Editor = tc.editor
+			errs := p.Validate()
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("expected %d warnings but got %v", len(tc.expected), errs)
+			}
+			for i, expected := range tc.expected {
+				if !keps.IsWarning(errs[i]) || !strings.Contains(errs[i].Error(), expected) {
+					t.Errorf("expected a warning containing %q but got %v", expected, errs[i])
+				}
+			}
+		})
+	}
+}
@@ -19,8 +19,16 @@ package keps
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
@@ -33,7 +41,160 @@ func (p *Proposals) AddProposal(proposal *Proposal) {
 	*p = append(*p, proposal)
 }
 
+// Walk calls fn for each proposal in order. It stops at the first error fn
+// returns and returns that error.
+func (p Proposals) Walk(fn func(*Proposal) error) error {
+	for _, proposal := range p {
+		if err := fn(proposal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Count returns the number of proposals.
+func (p Proposals) Count() int {
+	return len(p)
+}
+
+// CountByStatus returns the number of proposals for each status.
+func (p Proposals) CountByStatus() map[string]int {
+	return p.countBy(func(proposal *Proposal) string { return proposal.Status })
+}
+
+// CountBySIG returns the number of proposals owned by each SIG.
+func (p Proposals) CountBySIG() map[string]int {
+	return p.countBy(func(proposal *Proposal) string { return proposal.OwningSIG })
+}
+
+// CountByKey returns the number of proposals that set each top-level
+// metadata key, including keys that end up in Extra.
+func (p Proposals) CountByKey() map[string]int {
+	counts := map[string]int{}
+	for _, proposal := range p {
+		for _, key := range proposal.keys {
+			counts[key]++
+		}
+	}
+	return counts
+}
+
+// GroupBySIG returns the proposals owned by each SIG, in the order of p.
+func (p Proposals) GroupBySIG() map[string]Proposals {
+	return p.GroupBy(func(proposal *Proposal) string { return proposal.OwningSIG })
+}
+
+// GroupBy returns the proposals for each value that key returns for them,
+// in the order of p.
+func (p Proposals) GroupBy(key func(*Proposal) string) map[string]Proposals {
+	groups := map[string]Proposals{}
+	for _, proposal := range p {
+		groups[key(proposal)] = append(groups[key(proposal)], proposal)
+	}
+	return groups
+}
+
+// LatestUpdated returns the proposal with the most recent last-updated
+// date, or nil if none has a valid one. Proposals updated on the same day
+// are ordered by title, and the first title wins.
+func (p Proposals) LatestUpdated() *Proposal {
+	return p.updatedFirst(func(a, b time.Time) bool { return a.After(b) })
+}
+
+// OldestUpdated returns the proposal with the least recent last-updated
+// date, or nil if none has a valid one. Ties are broken like in
+// LatestUpdated.
+func (p Proposals) OldestUpdated() *Proposal {
+	return p.updatedFirst(func(a, b time.Time) bool { return a.Before(b) })
+}
+
+// updatedFirst returns the proposal whose last-updated date comes first
+// according to before, skipping proposals without a valid date.
+func (p Proposals) updatedFirst(before func(a, b time.Time) bool) *Proposal {
+	var first *Proposal
+	var firstUpdated time.Time
+	for _, proposal := range p {
+		updated, ok := proposal.Updated()
+		if !ok {
+			continue
+		}
+		if first == nil || before(updated, firstUpdated) ||
+			(updated.Equal(firstUpdated) && proposal.Title < first.Title) {
+			first, firstUpdated = proposal, updated
+		}
+	}
+	return first
+}
+
+// Filter returns the proposals for which keep returns true, in the order of
+// p. Predicates can be combined freely, for example to select the
+// implementable KEPs of sig-node or sig-storage:
+//
+//	proposals.Filter(func(p *keps.Proposal) bool {
+//		return p.Status == "implementable" &&
+//			(p.OwningSIG == "sig-node" || p.OwningSIG == "sig-storage")
+//	})
+func (p Proposals) Filter(keep func(*Proposal) bool) Proposals {
+	filtered := Proposals{}
+	for _, proposal := range p {
+		if keep(proposal) {
+			filtered = append(filtered, proposal)
+		}
+	}
+	return filtered
+}
+
+// FilterByStatus returns the proposals that have one of the given statuses.
+func (p Proposals) FilterByStatus(statuses ...string) Proposals {
+	return p.filterBy(func(proposal *Proposal) string { return proposal.Status }, statuses)
+}
+
+// ExcludeStatus returns the proposals that have none of the given statuses.
+func (p Proposals) ExcludeStatus(statuses ...string) Proposals {
+	return p.Filter(func(proposal *Proposal) bool {
+		for _, status := range statuses {
+			if proposal.Status == status {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// FilterBySIG returns the proposals that are owned by one of the given SIGs.
+func (p Proposals) FilterBySIG(sigs ...string) Proposals {
+	return p.filterBy(func(proposal *Proposal) string { return proposal.OwningSIG }, sigs)
+}
+
+// FilterByFeatureGate returns the proposals that declare the named feature
+// gate.
+func (p Proposals) FilterByFeatureGate(name string) Proposals {
+	return p.Filter(func(proposal *Proposal) bool { return proposal.HasFeatureGate(name) })
+}
+
+func (p Proposals) filterBy(key func(*Proposal) string, values []string) Proposals {
+	return p.Filter(func(proposal *Proposal) bool {
+		for _, value := range values {
+			if key(proposal) == value {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (p Proposals) countBy(key func(*Proposal) string) map[string]int {
+	counts := map[string]int{}
+	for _, proposal := range p {
+		counts[key(proposal)]++
+	}
+	return counts
+}
+
 type Proposal struct {
+	// KEPNumber is the number some older KEPs record in their metadata.
+	// Newer KEPs are numbered by their path instead, see Number.
+	KEPNumber         *int     `yaml:"kep-number,omitempty"`
 	Title             string   `yaml:"title"`
 	Authors           []string `yaml:,flow`
 	OwningSIG         string   `yaml:"owning-sig"`
@@ -47,50 +208,765 @@ type Proposal struct {
 	SeeAlso           []string `yaml:"see-also,omitempty"`
 	Replaces          []string `yaml:"replaces,omitempty"`
 	SupersededBy      []string `yaml:"superseded-by,omitempty"`
+	TrackingIssue     string   `yaml:"tracking-issue,omitempty"`
+
+	FeatureGates     []FeatureGate `yaml:"feature-gates,omitempty"`
+	DisableSupported bool          `yaml:"disable-supported,omitempty"`
+
+	// Stage is the maturity the proposal currently targets, such as alpha,
+	// beta or stable. LatestMilestone is the release the proposal last
+	// targeted. It should be the highest version in Milestone, which maps
+	// stages to the release they were reached in.
+	Stage           string            `yaml:"stage,omitempty"`
+	LatestMilestone string            `yaml:"latest-milestone,omitempty"`
+	Milestone       map[string]string `yaml:"milestone,omitempty"`
+
+	// Extra holds top-level metadata keys that are not modeled above.
+	// Parse only accepts keys the Parser allows.
+	Extra map[string]interface{} `yaml:",inline"`
 
 	Filename string `yaml:"-"`
 	Error    error  `yaml:"-"`
-	Contents string `yaml:"-"`
+	// Warnings are problems found while parsing that, unlike Error, do not
+	// stop the proposal from being used, such as a kep.yaml that disagrees
+	// with the frontmatter. Callers decide how to report them.
+	Warnings []error `yaml:"-"`
+	// ReviewersBySIG and ApproversBySIG hold the people of SIG scoped
+	// entries, such as "- sig-node: [@alice]", by SIG. The same people are
+	// also part of Reviewers and Approvers.
+	ReviewersBySIG map[string][]string `yaml:"-"`
+	ApproversBySIG map[string][]string `yaml:"-"`
+	Contents       string              `yaml:"-"`
+
+	// bodyLines maps each line of Contents to its line number in the
+	// parsed file.
+	bodyLines []int
+	// keys are the top-level metadata keys set by the parsed file, sorted.
+	keys []string
+	// rawFrontmatter is the frontmatter block of the parsed file as written.
+	rawFrontmatter string
+	// fsys and dir are where ParseFile read the proposal from, so that
+	// files the body refers to can be checked. fsys is nil for proposals
+	// that were not read with ParseFile.
+	fsys fs.FS
+	dir  string
+	// bodyDropped is set by DropBody, so that the body is left out of the
+	// json output rather than written as empty.
+	bodyDropped bool
 }
 
-type Parser struct{}
+// Keys returns the top-level metadata keys set in the parsed KEP, sorted.
+// It is empty for proposals that were not parsed.
+func (p *Proposal) Keys() []string {
+	return append([]string(nil), p.keys...)
+}
+
+// RawFrontmatter returns the frontmatter of the parsed KEP exactly as it was
+// written, without the --- delimiters. Unlike the decoded fields it keeps
+// unknown keys, comments and formatting. It is empty for KEPs without
+// frontmatter and doesn't include a kep.yaml merged by ParseFile.
+func (p *Proposal) RawFrontmatter() string {
+	return p.rawFrontmatter
+}
+
+// DropBody releases the body of the proposal once it is no longer needed,
+// such as after validation when only the metadata is written out. Checks of
+// the body, Validate included, must run before it is called.
+func (p *Proposal) DropBody() {
+	p.Contents = ""
+	p.bodyLines = nil
+	p.bodyDropped = true
+}
+
+// FeatureGate is a feature gate introduced by a proposal.
+type FeatureGate struct {
+	Name       string   `yaml:"name"`
+	Components []string `yaml:"components,omitempty"`
+}
+
+// HasFeatureGate reports whether the proposal declares the feature gate with
+// exactly the given name.
+func (p *Proposal) HasFeatureGate(name string) bool {
+	for _, gate := range p.FeatureGates {
+		if gate.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether p and other have the same metadata and exactly the
+// same body. Empty and missing lists are treated as equal. Filename and
+// Error are not compared.
+func (p *Proposal) Equal(other *Proposal) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.equalMetadata(other) && p.Contents == other.Contents
+}
+
+// EqualNormalized is like Equal but ignores differences in whitespace in the
+// body, such as reflowed paragraphs or trailing blank lines.
+func (p *Proposal) EqualNormalized(other *Proposal) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.equalMetadata(other) &&
+		strings.Join(strings.Fields(p.Contents), " ") == strings.Join(strings.Fields(other.Contents), " ")
+}
+
+func (p *Proposal) equalMetadata(other *Proposal) bool {
+	return equalNumbers(p.KEPNumber, other.KEPNumber) &&
+		p.Title == other.Title &&
+		equalStrings(p.Authors, other.Authors) &&
+		p.OwningSIG == other.OwningSIG &&
+		equalStrings(p.ParticipatingSIGs, other.ParticipatingSIGs) &&
+		equalStrings(p.Reviewers, other.Reviewers) &&
+		equalStrings(p.Approvers, other.Approvers) &&
+		p.Editor == other.Editor &&
+		p.CreationDate == other.CreationDate &&
+		p.LastUpdated == other.LastUpdated &&
+		p.Status == other.Status &&
+		equalStrings(p.SeeAlso, other.SeeAlso) &&
+		equalStrings(p.Replaces, other.Replaces) &&
+		equalStrings(p.SupersededBy, other.SupersededBy) &&
+		p.TrackingIssue == other.TrackingIssue &&
+		equalFeatureGates(p.FeatureGates, other.FeatureGates) &&
+		p.DisableSupported == other.DisableSupported &&
+		p.Stage == other.Stage &&
+		p.LatestMilestone == other.LatestMilestone &&
+		(len(p.Milestone) == 0 && len(other.Milestone) == 0 || reflect.DeepEqual(p.Milestone, other.Milestone)) &&
+		(len(p.Extra) == 0 && len(other.Extra) == 0 || reflect.DeepEqual(p.Extra, other.Extra))
+}
+
+func equalNumbers(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalFeatureGates(a, b []FeatureGate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || !equalStrings(a[i].Components, b[i].Components) {
+			return false
+		}
+	}
+	return true
+}
+
+// DateFormat is the layout of the creation-date and last-updated fields.
+const DateFormat = "2006-01-02"
+
+// Created returns the parsed creation-date of the proposal. ok is false if
+// the date is empty or not a valid date.
+func (p *Proposal) Created() (t time.Time, ok bool) {
+	return parseDate(p.CreationDate)
+}
+
+// Updated returns the parsed last-updated date of the proposal. ok is false
+// if the date is empty or not a valid date.
+func (p *Proposal) Updated() (t time.Time, ok bool) {
+	return parseDate(p.LastUpdated)
+}
+
+// MilestoneForStage returns the release the proposal reached stage in, such
+// as "v1.18" for "beta", from its milestone map. Stages are compared
+// ignoring case, and "ga" is the same stage as "stable". ok is false if the
+// map has no release for the stage.
+func (p *Proposal) MilestoneForStage(stage string) (milestone string, ok bool) {
+	stage = canonicalStage(stage)
+	if milestone := p.Milestone[stage]; milestone != "" {
+		return milestone, true
+	}
+	keys := make([]string, 0, len(p.Milestone))
+	for key := range p.Milestone {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if canonicalStage(key) == stage && p.Milestone[key] != "" {
+			return p.Milestone[key], true
+		}
+	}
+	return "", false
+}
+
+func canonicalStage(stage string) string {
+	if stage = strings.ToLower(strings.TrimSpace(stage)); stage == "ga" {
+		return "stable"
+	}
+	return stage
+}
+
+func parseDate(value string) (time.Time, bool) {
+	t, err := time.Parse(DateFormat, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+type Parser struct {
+	// AllowedKeys are top-level metadata keys that are permitted even though
+	// they are not modeled by Proposal. Their values end up in
+	// Proposal.Extra.
+	AllowedKeys []string
+	// StrictYAML rejects every top-level metadata key that Proposal does not
+	// model, AllowedKeys included, naming the key and its line.
+	StrictYAML bool
+}
+
+// A KEP directory holds the metadata of a KEP in MetadataFile and its body
+// in BodyFile. Older KEPs are a single markdown file with frontmatter.
+const (
+	MetadataFile = "kep.yaml"
+	BodyFile     = "README.md"
+)
 
 func (p *Parser) Parse(in io.Reader) *Proposal {
+	proposal, metadata := p.split(in)
+	if proposal.Error != nil {
+		return proposal
+	}
+	if metadata == nil {
+		metadata = &frontmatter{}
+	}
+	proposal.Error = p.decode(metadata, proposal)
+	return proposal
+}
+
+// ParseBytes parses the KEP in contents like Parse.
+func (p *Parser) ParseBytes(contents []byte) *Proposal {
+	return p.Parse(bytes.NewReader(contents))
+}
+
+// ParseString parses the KEP in contents like Parse.
+func (p *Parser) ParseString(contents string) *Proposal {
+	return p.Parse(strings.NewReader(contents))
+}
+
+// ParseFile parses the KEP called name in fsys. If there is a kep.yaml in
+// the same directory, the metadata is read from it and merged with the
+// frontmatter of the KEP, if it has any. Values from kep.yaml win, and keys
+// that have different values in both are reported as warnings by Validate.
+// A KEP without frontmatter must have a kep.yaml.
+func (p *Parser) ParseFile(fsys fs.FS, name string) *Proposal {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return &Proposal{Error: &ReadError{errors.Wrap(err, "error reading file")}}
+	}
+	defer file.Close()
+	proposal, metadata := p.split(file)
+	if proposal.Error != nil {
+		return proposal
+	}
+
+	metadataName := path.Join(path.Dir(name), MetadataFile)
+	data, err := fs.ReadFile(fsys, metadataName)
+	switch {
+	case os.IsNotExist(err) && metadata == nil:
+		proposal.Error = errors.Errorf("%s has no frontmatter and there is no %s next to it", name, MetadataFile)
+	case os.IsNotExist(err):
+		proposal.Error = p.decode(metadata, proposal)
+	case err != nil:
+		proposal.Error = &ReadError{errors.Wrapf(err, "error reading %s", metadataName)}
+	case metadata == nil:
+		proposal.Error = errors.Wrapf(p.decode(&frontmatter{data: data}, proposal), "%s", metadataName)
+	default:
+		proposal.Error = p.merge(metadata, &frontmatter{data: data}, proposal)
+	}
+	proposal.fsys, proposal.dir = fsys, path.Dir(name)
+	return proposal
+}
+
+// ReadError is the Error of a proposal whose KEP file, or kep.yaml, could
+// not be read, such as because of its permissions, as opposed to one that
+// could not be parsed.
+type ReadError struct {
+	Err error
+}
+
+func (r *ReadError) Error() string {
+	return r.Err.Error()
+}
+
+// IsReadError reports whether err is a ReadError.
+func IsReadError(err error) bool {
+	_, ok := err.(*ReadError)
+	return ok
+}
+
+// parseError is a problem with the structure of a KEP file, such as its
+// frontmatter, along with the line and key it was found at, if known.
+type parseError struct {
+	message string
+	key     string
+	line    int
+}
+
+func (e *parseError) Error() string {
+	return e.message
+}
+
+// Key returns the metadata key the problem is about, if any.
+func (e *parseError) Key() string {
+	return e.key
+}
+
+// Line returns the line of the problem, or 0 if it is not known.
+func (e *parseError) Line() int {
+	return e.line
+}
+
+// frontmatter is a YAML metadata block along with the number of lines that
+// come before it in its file.
+type frontmatter struct {
+	data   []byte
+	offset int
+}
+
+// split reads a KEP file into its body, which is stored in the returned
+// proposal, and its frontmatter, which is nil if the file has none. The
+// frontmatter is opened by a line that is exactly --- and ends at the next
+// one. Only blank lines, headings and the opening fence of a code block may
+// come before it, and a warning asks for it to be moved to the top if any
+// do. Every later line is body, so that horizontal rules and table
+// separators in the body are left alone.
+func (p *Parser) split(in io.Reader) (*Proposal, *frontmatter) {
 	scanner := bufio.NewScanner(in)
-	count := 0
-	metadata := []byte{}
+	var metadata *frontmatter
 	var body bytes.Buffer
-	for scanner.Scan() {
-		line := scanner.Text() + "\n"
-		if strings.Contains(line, "---") {
-			count++
+	var bodyLines []int
+	// closing is the line of the separator that ended the frontmatter. If
+	// the lines after it up to another separator still look like metadata,
+	// that separator was added by mistake in the middle of the frontmatter.
+	inFrontmatter, closing, extraSeparator := false, 0, 0
+	stillMetadata, hasKey := true, false
+	leading := true
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		text := scanner.Text()
+		separator := strings.TrimRight(text, " \t\r") == "---"
+		switch {
+		case leading && separator:
+			leading, inFrontmatter = false, true
+			metadata = &frontmatter{data: []byte{}, offset: lineNumber}
+			continue
+		case leading:
+			leading = reLeadingLine.MatchString(text)
+		case inFrontmatter && separator:
+			inFrontmatter, closing = false, lineNumber
+			continue
+		case inFrontmatter:
+			metadata.data = append(metadata.data, []byte(text+"\n")...)
 			continue
 		}
-		if count == 1 {
-			metadata = append(metadata, []byte(line)...)
-		} else {
-			body.WriteString(line)
+		if closing != 0 && stillMetadata {
+			if separator {
+				if hasKey {
+					extraSeparator = closing
+				}
+				stillMetadata = false
+			} else {
+				stillMetadata = reMetadataLine.MatchString(text)
+				if match := reMetadataKey.FindStringSubmatch(text); match != nil && modeledKey(match[1]) {
+					hasKey = true
+				}
+			}
 		}
+		body.WriteString(text + "\n")
+		bodyLines = append(bodyLines, lineNumber)
 	}
 	proposal := &Proposal{
-		Contents: body.String(),
+		Contents:  body.String(),
+		bodyLines: bodyLines,
 	}
 	if err := scanner.Err(); err != nil {
 		proposal.Error = errors.Wrap(err, "error reading file")
-		return proposal
+		return proposal, nil
+	}
+	if inFrontmatter && metadata.offset > 1 {
+		// a horizontal rule near the top of a body without frontmatter
+		for i, line := range append([]string{"---"}, strings.SplitAfter(string(metadata.data), "\n")...) {
+			if line != "" {
+				body.WriteString(strings.TrimSuffix(line, "\n") + "\n")
+				bodyLines = append(bodyLines, metadata.offset+i)
+			}
+		}
+		proposal.Contents, proposal.bodyLines = body.String(), bodyLines
+		return proposal, nil
+	}
+	if inFrontmatter {
+		proposal.Error = &parseError{
+			message: fmt.Sprintf("unterminated frontmatter block starting at line %d", metadata.offset),
+			line:    metadata.offset,
+		}
+		return proposal, nil
+	}
+	if extraSeparator != 0 {
+		proposal.Error = &parseError{
+			message: fmt.Sprintf("extra --- at line %d inside the frontmatter block starting at line %d; the metadata must be a single YAML document", extraSeparator, metadata.offset),
+			line:    extraSeparator,
+		}
+		return proposal, nil
+	}
+	if metadata != nil {
+		proposal.rawFrontmatter = string(metadata.data)
+	}
+	if metadata != nil && metadata.offset > 1 {
+		proposal.Warnings = append(proposal.Warnings, &parseError{
+			message: fmt.Sprintf("the frontmatter block starts at line %d; move it to the top of the file", metadata.offset),
+			line:    metadata.offset,
+		})
+	}
+	return proposal, metadata
+}
+
+var (
+	// reMetadataLine matches the lines of a YAML block: keys, indented
+	// values, list entries, comments and blank lines.
+	reMetadataLine = regexp.MustCompile(`^(\s*|\s*#.*|\s+.*|- .*|[\w-]+:(\s.*)?)$`)
+	reMetadataKey  = regexp.MustCompile(`^([\w-]+):(\s|$)`)
+	// reLeadingLine matches the lines that may come before the frontmatter:
+	// blank lines, headings and the opening fence of a code block.
+	reLeadingLine = regexp.MustCompile("^(\\s*|#{1,6}\\s.*|```\\w*)$")
+)
+
+// modeledKey reports whether key is one of the metadataKeys, ignoring case.
+func modeledKey(key string) bool {
+	for _, modeled := range metadataKeys {
+		if strings.EqualFold(key, modeled) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTabs returns an error if the metadata uses tabs for indentation.
+// YAML does not allow them and the parser's error for them is hard to act
+// on.
+func (m *frontmatter) checkTabs() error {
+	for i, line := range strings.Split(string(m.data), "\n") {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if strings.Contains(indent, "\t") {
+			return &parseError{
+				message: fmt.Sprintf("tab character in YAML indentation at line %d; use spaces", m.offset+i+1),
+				line:    m.offset + i + 1,
+			}
+		}
+	}
+	return nil
+}
+
+// checkModeledKeys returns an error for the first top-level key of the
+// metadata that Proposal does not model, with its line. Keys that only
+// differ in case from a modeled key are left for canonicalizeKeys.
+func (m *frontmatter) checkModeledKeys() error {
+	var keys yaml.MapSlice
+	if err := yaml.Unmarshal(m.data, &keys); err != nil {
+		return errors.Wrap(err, "error unmarshaling YAML")
+	}
+	for _, item := range keys {
+		key := fmt.Sprint(item.Key)
+		if modeledKey(key) {
+			continue
+		}
+		if i, ok := m.keyLine(key); ok {
+			return &parseError{
+				message: fmt.Sprintf("unknown key %q at line %d in KEP metadata", key, m.offset+i+1),
+				key:     key,
+				line:    m.offset + i + 1,
+			}
+		}
+		return &parseError{message: fmt.Sprintf("unknown key %q in KEP metadata", key), key: key}
+	}
+	return nil
+}
+
+// keyLine returns the zero based line of the metadata where the top-level
+// key, which may be quoted, is set.
+func (m *frontmatter) keyLine(key string) (int, bool) {
+	for i, line := range strings.Split(string(m.data), "\n") {
+		line = strings.TrimPrefix(strings.TrimPrefix(line, `"`), "'")
+		if !strings.HasPrefix(line, key) {
+			continue
+		}
+		if rest := strings.TrimLeft(strings.TrimPrefix(line, key), `"' `); strings.HasPrefix(rest, ":") {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// decode checks the structure of the metadata and decodes it into proposal.
+func (p *Parser) decode(metadata *frontmatter, proposal *Proposal) error {
+	if err := metadata.checkTabs(); err != nil {
+		return err
+	}
+	if p.StrictYAML {
+		if err := metadata.checkModeledKeys(); err != nil {
+			return err
+		}
 	}
 
 	// First do structural checks
 	test := map[interface{}]interface{}{}
-	if err := yaml.Unmarshal(metadata, test); err != nil {
-		proposal.Error = errors.Wrap(err, "error unmarshaling YAML")
-		return proposal
+	if err := yaml.Unmarshal(metadata.data, test); err != nil {
+		return errors.Wrap(err, "error unmarshaling YAML")
+	}
+	canonicalized := p.canonicalizeKeys(test, proposal)
+	if flattenScopedPeople(test, proposal) || canonicalized {
+		data, err := yaml.Marshal(test)
+		if err != nil {
+			return err
+		}
+		metadata = &frontmatter{data: data}
 	}
 	if err := validations.ValidateStructure(test); err != nil {
-		proposal.Error = errors.Wrap(err, "error validating KEP metadata")
-		return proposal
+		return errors.Wrap(err, "error validating KEP metadata")
+	}
+	proposal.keys = make([]string, 0, len(test))
+	for key := range test {
+		proposal.keys = append(proposal.keys, fmt.Sprint(key))
 	}
+	sort.Strings(proposal.keys)
 
-	proposal.Error = yaml.UnmarshalStrict(metadata, proposal)
-	return proposal
+	if err := yaml.UnmarshalStrict(metadata.data, proposal); err != nil {
+		return err
+	}
+	return p.checkExtraKeys(proposal.Extra)
+}
+
+// merge decodes the frontmatter of a KEP together with its kep.yaml into
+// proposal. Keys from kep.yaml win over the frontmatter, and keys with
+// different values in both are recorded as warnings.
+func (p *Parser) merge(front, file *frontmatter, proposal *Proposal) error {
+	if err := front.checkTabs(); err != nil {
+		return err
+	}
+	if err := file.checkTabs(); err != nil {
+		return errors.Wrapf(err, "%s", MetadataFile)
+	}
+	if p.StrictYAML {
+		if err := front.checkModeledKeys(); err != nil {
+			return err
+		}
+		if err := file.checkModeledKeys(); err != nil {
+			return errors.Wrapf(err, "%s", MetadataFile)
+		}
+	}
+	merged := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(front.data, merged); err != nil {
+		return errors.Wrap(err, "error unmarshaling YAML")
+	}
+	overrides := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(file.data, overrides); err != nil {
+		return errors.Wrapf(err, "error unmarshaling %s", MetadataFile)
+	}
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, fmt.Sprint(key))
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value, found := merged[key]; found {
+			if err := validations.ValidateMetadataAgrees(key, value, overrides[key], MetadataFile); err != nil {
+				proposal.Warnings = append(proposal.Warnings, err)
+			}
+		}
+		merged[key] = overrides[key]
+	}
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	return p.decode(&frontmatter{data: data}, proposal)
+}
+
+// canonicalizeKeys renames the keys of metadata that only differ in case
+// from a modeled or allowed key, such as Owning-SIG, to that key and records
+// a warning for each. It reports whether any key was renamed. A key is left
+// alone if metadata also has it spelled correctly.
+func (p *Parser) canonicalizeKeys(metadata map[interface{}]interface{}, proposal *Proposal) bool {
+	canonical := map[string]string{}
+	for _, key := range append(append([]string(nil), metadataKeys...), p.AllowedKeys...) {
+		canonical[strings.ToLower(key)] = key
+	}
+	var renames []string
+	for key := range metadata {
+		k, ok := key.(string)
+		if !ok {
+			continue
+		}
+		if c, found := canonical[strings.ToLower(k)]; found && c != k {
+			if _, taken := metadata[c]; !taken {
+				renames = append(renames, k)
+			}
+		}
+	}
+	sort.Strings(renames)
+	for _, key := range renames {
+		c := canonical[strings.ToLower(key)]
+		if _, taken := metadata[c]; taken {
+			// another casing of the same key was already renamed
+			continue
+		}
+		metadata[c] = metadata[key]
+		delete(metadata, key)
+		proposal.Warnings = append(proposal.Warnings, validations.ValidateKeyCase(key, c))
+	}
+	return len(renames) > 0
+}
+
+// flattenScopedPeople rewrites the SIG scoped entries of reviewers and
+// approvers, such as
+//
+//	reviewers:
+//	  - "@bob"
+//	  - sig-node: ["@alice"]
+//
+// into people of the flat list, and records the SIG of each of them in
+// ReviewersBySIG or ApproversBySIG. It reports whether anything was
+// rewritten. Entries of any other shape are left for decoding to reject.
+func flattenScopedPeople(metadata map[interface{}]interface{}, proposal *Proposal) bool {
+	rewritten := false
+	lists := []struct {
+		key    string
+		scoped *map[string][]string
+	}{
+		{"reviewers", &proposal.ReviewersBySIG},
+		{"approvers", &proposal.ApproversBySIG},
+	}
+	for _, list := range lists {
+		values, ok := metadata[list.key].([]interface{})
+		if !ok {
+			continue
+		}
+		var flat []interface{}
+		scoped := false
+		for _, value := range values {
+			scopes, ok := value.(map[interface{}]interface{})
+			if !ok {
+				flat = append(flat, value)
+				continue
+			}
+			people, bySIG, ok := scopedPeople(scopes)
+			if !ok {
+				flat = append(flat, value)
+				continue
+			}
+			if *list.scoped == nil {
+				*list.scoped = map[string][]string{}
+			}
+			for sig, handles := range bySIG {
+				(*list.scoped)[sig] = append((*list.scoped)[sig], handles...)
+			}
+			flat = append(flat, people...)
+			scoped = true
+		}
+		if scoped {
+			metadata[list.key] = flat
+			rewritten = true
+		}
+	}
+	return rewritten
+}
+
+// scopedPeople returns the people of a SIG scoped entry, in order of SIG
+// name, along with the people of each SIG. A SIG may list several people
+// or a single one. It reports false if the entry has any other shape.
+func scopedPeople(scopes map[interface{}]interface{}) ([]interface{}, map[string][]string, bool) {
+	sigs := make([]string, 0, len(scopes))
+	for key := range scopes {
+		sig, ok := key.(string)
+		if !ok {
+			return nil, nil, false
+		}
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+	var people []interface{}
+	bySIG := map[string][]string{}
+	for _, sig := range sigs {
+		var handles []interface{}
+		switch v := scopes[sig].(type) {
+		case string:
+			handles = []interface{}{v}
+		case []interface{}:
+			handles = v
+		default:
+			return nil, nil, false
+		}
+		for _, handle := range handles {
+			h, ok := handle.(string)
+			if !ok {
+				return nil, nil, false
+			}
+			people = append(people, h)
+			bySIG[sig] = append(bySIG[sig], h)
+		}
+	}
+	return people, bySIG, true
+}
+
+// metadataKeys are the top-level metadata keys modeled by Proposal, named
+// the way yaml decodes them.
+var metadataKeys = func() []string {
+	var keys []string
+	t := reflect.TypeOf(Proposal{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		switch {
+		case tag[0] == "-", len(tag) > 1 && tag[1] == "inline":
+			continue
+		case tag[0] == "":
+			// like yaml, fall back to the lowercased field name
+			keys = append(keys, strings.ToLower(field.Name))
+		default:
+			keys = append(keys, tag[0])
+		}
+	}
+	return keys
+}()
+
+func (p *Parser) checkExtraKeys(extra map[string]interface{}) error {
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !p.allowed(key) {
+			return &parseError{message: fmt.Sprintf("unknown key %q in KEP metadata", key), key: key}
+		}
+	}
+	return nil
+}
+
+func (p *Parser) allowed(key string) bool {
+	for _, allowed := range p.AllowedKeys {
+		if key == allowed {
+			return true
+		}
+	}
+	return false
 }
@@ -17,12 +17,29 @@ limitations under the License.
 package keps_test
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"k8s.io/enhancements/pkg/kepval/keps"
 )
 
+// validProposal returns a proposal that passes Validate, for tests to
+// change only the part they check.
+func validProposal() *keps.Proposal {
+	return &keps.Proposal{
+		Title:     "test",
+		Authors:   []string{"@jpbetz"},
+		OwningSIG: "sig-api-machinery",
+		Status:    "provisional",
+		Contents:  strings.Repeat("word ", keps.MinWordCount),
+	}
+}
+
 func TestValidParsing(t *testing.T) {
 	testcases := []struct {
 		name         string
@@ -69,3 +86,1414 @@ status: provisional
 		})
 	}
 }
+
+func TestInvalidParsing(t *testing.T) {
+	testcases := []struct {
+		name          string
+		fileContents  string
+		expectedError string
+	}{
+		{
+			"unterminated frontmatter",
+			`---
+title: test
+owning-sig: sig-api-machinery
+
+# Title
+
+Some text.
+`,
+			"unterminated frontmatter block starting at line 1",
+		},
+		{
+			"tab in frontmatter indentation",
+			"---\ntitle: test\nauthors:\n\t- \"@jpbetz\"\nowning-sig: sig-api-machinery\n---\n",
+			"tab character in YAML indentation at line 4; use spaces",
+		},
+		{
+			"separator in the middle of the frontmatter",
+			`---
+title: test
+---
+owning-sig: sig-api-machinery
+status: provisional
+---
+
+# Title
+`,
+			"extra --- at line 3 inside the frontmatter block starting at line 1; the metadata must be a single YAML document",
+		},
+		{
+			"kep-number that is not an integer",
+			"---\nkep-number: four\ntitle: test\nowning-sig: sig-api-machinery\n---\n",
+			`"kep-number" must be an integer but it is a string: four`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &keps.Parser{}
+			contents := strings.NewReader(tc.fileContents)
+			out := p.Parse(contents)
+			if out.Error == nil {
+				t.Fatal("expected an error but got none")
+			}
+			if !strings.Contains(out.Error.Error(), tc.expectedError) {
+				t.Fatalf("expected error %q but got %q", tc.expectedError, out.Error)
+			}
+		})
+	}
+}
+
+func TestHorizontalRuleAfterFrontmatter(t *testing.T) {
+	kep := (&keps.Parser{}).Parse(strings.NewReader("---\ntitle: test\nowning-sig: sig-api-machinery\n---\nNote: this is prose.\n\n---\n\n# Title\n"))
+	if kep.Error != nil {
+		t.Fatalf("unexpected error: %v", kep.Error)
+	}
+}
+
+func TestFrontmatterAfterHeadings(t *testing.T) {
+	testcases := []struct {
+		name     string
+		contents string
+		body     string
+	}{
+		{
+			name:     "heading",
+			contents: "# Title\n\n---\ntitle: test\nowning-sig: sig-api-machinery\n---\n\n## Summary\n",
+			body:     "# Title\n\n\n## Summary\n",
+		},
+		{
+			name:     "code block",
+			contents: "# Title\n\n## Metadata\n\n```yaml\n---\ntitle: test\nowning-sig: sig-api-machinery\n---\n```\n\n## Summary\n",
+			body:     "# Title\n\n## Metadata\n\n```yaml\n```\n\n## Summary\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			kep := (&keps.Parser{}).Parse(strings.NewReader(tc.contents))
+			if kep.Error != nil {
+				t.Fatalf("unexpected error: %v", kep.Error)
+			}
+			if kep.Title != "test" {
+				t.Errorf("expected the title from the frontmatter but got %q", kep.Title)
+			}
+			if kep.Contents != tc.body {
+				t.Errorf("expected the body %q but got %q", tc.body, kep.Contents)
+			}
+			if len(kep.Warnings) != 1 || !strings.Contains(kep.Warnings[0].Error(), "move it to the top") {
+				t.Errorf("expected a warning about the frontmatter position but got %v", kep.Warnings)
+			}
+		})
+	}
+}
+
+func TestHorizontalRuleWithoutFrontmatter(t *testing.T) {
+	const body = "# Title\n\n---\n\nText.\n"
+	fsys := fstest.MapFS{
+		"split/README.md": {Data: []byte(body)},
+		"split/kep.yaml":  {Data: []byte("title: test\nowning-sig: sig-api-machinery\n")},
+	}
+	kep := (&keps.Parser{}).ParseFile(fsys, "split/README.md")
+	if kep.Error != nil {
+		t.Fatalf("unexpected error: %v", kep.Error)
+	}
+	if kep.Contents != body {
+		t.Errorf("expected the whole body but got %q", kep.Contents)
+	}
+}
+
+func TestBodyTables(t *testing.T) {
+	const tables = "# Title\n\n| Stage | Release |\n|---|---|\n| alpha | v1.18 |\n\nNotes\n\n| Key | Value |\n| --- | --- |\n| a | b |\n"
+	const metadata = "title: test\nowning-sig: sig-api-machinery\n"
+	fsys := fstest.MapFS{
+		"single/0001-kep.md": {Data: []byte("---\n" + metadata + "---\n" + tables)},
+		"split/README.md":    {Data: []byte(tables)},
+		"split/kep.yaml":     {Data: []byte(metadata)},
+	}
+	for _, file := range []string{"single/0001-kep.md", "split/README.md"} {
+		t.Run(file, func(t *testing.T) {
+			kep := (&keps.Parser{}).ParseFile(fsys, file)
+			if kep.Error != nil {
+				t.Fatalf("unexpected error: %v", kep.Error)
+			}
+			if kep.Title != "test" {
+				t.Errorf("expected the title from the metadata but got %q", kep.Title)
+			}
+			if kep.Contents != tables {
+				t.Errorf("expected the whole body with its tables but got:\n%s", kep.Contents)
+			}
+		})
+	}
+}
+
+func TestCounts(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "a", OwningSIG: "sig-node", Status: "provisional"},
+		{Title: "b", OwningSIG: "sig-node", Status: "implementable"},
+		{Title: "c", OwningSIG: "sig-storage", Status: "implementable"},
+	}
+	if proposals.Count() != len(proposals) {
+		t.Fatalf("expected count %d but got %d", len(proposals), proposals.Count())
+	}
+	testcases := []struct {
+		name     string
+		counts   map[string]int
+		expected map[string]int
+	}{
+		{
+			name:     "by status",
+			counts:   proposals.CountByStatus(),
+			expected: map[string]int{"provisional": 1, "implementable": 2},
+		},
+		{
+			name:     "by sig",
+			counts:   proposals.CountBySIG(),
+			expected: map[string]int{"sig-node": 2, "sig-storage": 1},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			total := 0
+			for key, count := range tc.counts {
+				if tc.expected[key] != count {
+					t.Fatalf("expected %d for %q but got %d", tc.expected[key], key, count)
+				}
+				total += count
+			}
+			if total != len(proposals) {
+				t.Fatalf("expected counts to add up to %d but got %d", len(proposals), total)
+			}
+		})
+	}
+}
+
+func TestValidateStub(t *testing.T) {
+	testcases := []struct {
+		name       string
+		contents   string
+		expectStub bool
+	}{
+		{
+			name:       "empty body",
+			contents:   "",
+			expectStub: true,
+		},
+		{
+			name:       "short body",
+			contents:   "## Summary\n\nTBD\n",
+			expectStub: true,
+		},
+		{
+			name:     "long body",
+			contents: strings.Repeat("word ", keps.MinWordCount),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.Contents = tc.contents
+			stub := false
+			for _, err := range p.Validate() {
+				if !keps.IsWarning(err) {
+					t.Fatalf("expected only warnings but got an error: %v", err)
+				}
+				stub = true
+			}
+			if stub != tc.expectStub {
+				t.Fatalf("expected stub warning to be %v but got %v", tc.expectStub, stub)
+			}
+		})
+	}
+}
+
+func TestValidateDates(t *testing.T) {
+	testcases := []struct {
+		name         string
+		creationDate string
+		lastUpdated  string
+		expectWarn   bool
+	}{
+		{
+			name:         "updated after creation",
+			creationDate: "2019-01-01",
+			lastUpdated:  "2019-02-01",
+		},
+		{
+			name:         "updated on creation day",
+			creationDate: "2019-01-01",
+			lastUpdated:  "2019-01-01",
+		},
+		{
+			name:         "updated before creation",
+			creationDate: "2019-02-01",
+			lastUpdated:  "2019-01-01",
+			expectWarn:   true,
+		},
+		{
+			name:         "no last updated date",
+			creationDate: "2019-02-01",
+		},
+		{
+			name:        "updated within the clock skew",
+			lastUpdated: "2020-03-02",
+		},
+		{
+			name:        "updated in the future",
+			lastUpdated: "2020-03-03",
+			expectWarn:  true,
+		},
+	}
+	keps.Now = func() time.Time { return time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { keps.Now = time.Now }()
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.CreationDate = tc.creationDate
+			p.LastUpdated = tc.lastUpdated
+			errs := p.Validate()
+			if tc.expectWarn && (len(errs) == 0 || !keps.IsWarning(errs[0])) {
+				t.Fatal("expecting a warning")
+			}
+			if !tc.expectWarn && len(errs) != 0 {
+				t.Fatalf("did not expect an error: %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidateMilestones(t *testing.T) {
+	testcases := []struct {
+		name            string
+		latestMilestone string
+		milestone       map[string]string
+		expectWarn      bool
+	}{
+		{
+			name:            "latest is the highest",
+			latestMilestone: "v1.19",
+			milestone:       map[string]string{"alpha": "v1.9", "beta": "v1.10", "stable": "v1.19"},
+		},
+		{
+			name:            "versions compare numerically",
+			latestMilestone: "v1.10",
+			milestone:       map[string]string{"alpha": "v1.9", "beta": "v1.10"},
+		},
+		{
+			name:            "leading v is optional",
+			latestMilestone: "1.18",
+			milestone:       map[string]string{"alpha": "v1.18"},
+		},
+		{
+			name:            "latest is behind",
+			latestMilestone: "v1.18",
+			milestone:       map[string]string{"alpha": "v1.18", "beta": "v1.19"},
+			expectWarn:      true,
+		},
+		{
+			name:            "no milestone map",
+			latestMilestone: "v1.18",
+		},
+		{
+			name:      "no latest milestone",
+			milestone: map[string]string{"alpha": "v1.18"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.LatestMilestone = tc.latestMilestone
+			p.Milestone = tc.milestone
+			// beta and stable milestones also require the questionnaire
+			p.Contents = "## Production Readiness Review Questionnaire\n" + p.Contents
+			errs := p.Validate()
+			if tc.expectWarn && (len(errs) != 1 || !keps.IsWarning(errs[0])) {
+				t.Fatalf("expecting a warning but got %v", errs)
+			}
+			if !tc.expectWarn && len(errs) != 0 {
+				t.Fatalf("did not expect an error: %v", errs)
+			}
+		})
+	}
+}
+
+func TestSplitWarnings(t *testing.T) {
+	p := validProposal()
+	p.ParticipatingSIGs = []string{"sig-cli", "sig-cli"}
+	p.Contents = "too short"
+	failures, warnings := keps.SplitWarnings(p.Validate())
+	if len(failures) != 1 || keps.IsWarning(failures[0]) {
+		t.Errorf("expected the duplicate participating SIG error but got %v", failures)
+	}
+	if len(warnings) != 1 || !keps.IsWarning(warnings[0]) {
+		t.Errorf("expected the stub warning but got %v", warnings)
+	}
+}
+
+func TestScopedPeople(t *testing.T) {
+	testcases := []struct {
+		name              string
+		people            string
+		expectedReviewers []string
+		expectedApprovers []string
+		expectedBySIG     map[string][]string
+		expectedError     string
+	}{
+		{
+			name:              "flat lists",
+			people:            "reviewers:\n  - \"@bob\"\napprovers:\n  - \"@carol\"\n",
+			expectedReviewers: []string{"@bob"},
+			expectedApprovers: []string{"@carol"},
+		},
+		{
+			name: "scoped reviewers",
+			people: `reviewers:
+  - "@bob"
+  - sig-node: ["@alice", "@dave"]
+  - sig-storage: "@erin"
+    sig-apps: ["@frank"]
+approvers:
+  - "@carol"
+`,
+			expectedReviewers: []string{"@bob", "@alice", "@dave", "@frank", "@erin"},
+			expectedApprovers: []string{"@carol"},
+			expectedBySIG: map[string][]string{
+				"sig-node":    {"@alice", "@dave"},
+				"sig-apps":    {"@frank"},
+				"sig-storage": {"@erin"},
+			},
+		},
+		{
+			name:          "scoped entry with a nested list",
+			people:        "reviewers:\n  - sig-node: [[\"@alice\"]]\n",
+			expectedError: "cannot unmarshal",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			contents := "---\ntitle: test\nowning-sig: sig-node\n" + tc.people + "---\n"
+			kep := (&keps.Parser{}).Parse(strings.NewReader(contents))
+			if tc.expectedError != "" {
+				if kep.Error == nil || !strings.Contains(kep.Error.Error(), tc.expectedError) {
+					t.Fatalf("expected an error containing %q but got %v", tc.expectedError, kep.Error)
+				}
+				return
+			}
+			if kep.Error != nil {
+				t.Fatalf("unexpected error: %v", kep.Error)
+			}
+			if !reflect.DeepEqual(kep.Reviewers, tc.expectedReviewers) || !reflect.DeepEqual(kep.Approvers, tc.expectedApprovers) {
+				t.Fatalf("unexpected reviewers %v and approvers %v", kep.Reviewers, kep.Approvers)
+			}
+			if !reflect.DeepEqual(kep.ReviewersBySIG, tc.expectedBySIG) || kep.ApproversBySIG != nil {
+				t.Fatalf("unexpected reviewers by SIG %v and approvers by SIG %v", kep.ReviewersBySIG, kep.ApproversBySIG)
+			}
+		})
+	}
+}
+
+func TestMiscasedKeys(t *testing.T) {
+	contents := `---
+Title: test
+Owning-SIG: sig-api-machinery
+status: provisional
+Latest-Milestone: "v1.17"
+Stage-Notes: later
+---
+`
+	p := &keps.Parser{AllowedKeys: []string{"stage-notes"}}
+	kep := p.Parse(strings.NewReader(contents))
+	if kep.Error != nil {
+		t.Fatalf("unexpected error: %v", kep.Error)
+	}
+	if kep.Title != "test" || kep.OwningSIG != "sig-api-machinery" || kep.LatestMilestone != "v1.17" || kep.Extra["stage-notes"] != "later" {
+		t.Fatalf("expected the miscased keys to be recovered but got %+v", kep)
+	}
+	if keys := strings.Join(kep.Keys(), ","); keys != "latest-milestone,owning-sig,stage-notes,status,title" {
+		t.Errorf("unexpected keys %q", keys)
+	}
+	expected := []string{
+		`key "Latest-Milestone" is read as "latest-milestone"`,
+		`key "Owning-SIG" is read as "owning-sig"`,
+		`key "Stage-Notes" is read as "stage-notes"`,
+		`key "Title" is read as "title"`,
+	}
+	if len(kep.Warnings) != len(expected) {
+		t.Fatalf("expected %d warnings but got %v", len(expected), kep.Warnings)
+	}
+	for i, warning := range kep.Warnings {
+		if !strings.HasPrefix(warning.Error(), expected[i]) {
+			t.Errorf("expected a warning starting with %q but got %q", expected[i], warning)
+		}
+	}
+
+	// a correctly spelled key wins and the other one is unknown
+	kep = p.Parse(strings.NewReader("---\ntitle: test\nowning-sig: sig-api-machinery\nStatus: provisional\nstatus: implementable\n---\n"))
+	if kep.Error == nil || kep.Error.Error() != `unknown key "Status" in KEP metadata` {
+		t.Fatalf("expected the miscased duplicate to be unknown but got %v", kep.Error)
+	}
+}
+
+func TestRawFrontmatter(t *testing.T) {
+	metadata := `title: test  # kept as written
+owning-sig: sig-api-machinery
+Status: provisional
+`
+	kep := (&keps.Parser{}).Parse(strings.NewReader("---\n" + metadata + "---\n\n# Body\n"))
+	if kep.Error != nil {
+		t.Fatalf("unexpected error: %v", kep.Error)
+	}
+	kep.DropBody()
+	if raw := kep.RawFrontmatter(); raw != metadata {
+		t.Fatalf("expected %q but got %q", metadata, raw)
+	}
+	if raw := (&keps.Parser{}).Parse(strings.NewReader("# Body\n")).RawFrontmatter(); raw != "" {
+		t.Fatalf("expected no frontmatter but got %q", raw)
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	for _, contents := range []string{
+		"---\ntitle: test\nowning-sig: sig-node\n---\n\n# Body\n",
+		"\ufeff---\r\ntitle: test\r\nowning-sig: sig-node\r\n---\r\n\r\n# Body\r\n",
+		"---\ntitle: test\n",
+		"# Body\n",
+	} {
+		for name, kep := range map[string]*keps.Proposal{
+			"ParseBytes":  (&keps.Parser{}).ParseBytes([]byte(contents)),
+			"ParseString": (&keps.Parser{}).ParseString(contents),
+		} {
+			expected := (&keps.Parser{}).Parse(strings.NewReader(contents))
+			if fmt.Sprint(kep.Error) != fmt.Sprint(expected.Error) {
+				t.Errorf("%s(%q): expected error %v but got %v", name, contents, expected.Error, kep.Error)
+				continue
+			}
+			kep.Error, expected.Error = nil, nil
+			if !reflect.DeepEqual(kep, expected) {
+				t.Errorf("%s(%q): expected %+v but got %+v", name, contents, expected, kep)
+			}
+		}
+	}
+}
+
+func TestAllowedKeys(t *testing.T) {
+	contents := `---
+title: test
+owning-sig: sig-api-machinery
+experimental-key: some value
+---`
+	testcases := []struct {
+		name        string
+		allowedKeys []string
+		expectErr   bool
+	}{
+		{
+			name:      "unknown key is rejected",
+			expectErr: true,
+		},
+		{
+			name:        "allowed key is accepted",
+			allowedKeys: []string{"experimental-key"},
+		},
+		{
+			name:        "other allowed keys do not help",
+			allowedKeys: []string{"another-key"},
+			expectErr:   true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &keps.Parser{AllowedKeys: tc.allowedKeys}
+			out := p.Parse(strings.NewReader(contents))
+			if tc.expectErr {
+				if out.Error == nil {
+					t.Fatal("expecting an error")
+				}
+				return
+			}
+			if out.Error != nil {
+				t.Fatalf("did not expect an error: %v", out.Error)
+			}
+			if out.Extra["experimental-key"] != "some value" {
+				t.Fatalf("expected the allowed key to be kept but got %v", out.Extra)
+			}
+		})
+	}
+}
+
+func TestStrictYAML(t *testing.T) {
+	p := &keps.Parser{AllowedKeys: []string{"experimental-key"}, StrictYAML: true}
+	out := p.Parse(strings.NewReader("---\ntitle: test\nOwning-SIG: sig-api-machinery\n\"experimental-key\": some value\n---\n"))
+	if out.Error == nil || out.Error.Error() != `unknown key "experimental-key" at line 4 in KEP metadata` {
+		t.Fatalf("expected the allowed key to be rejected but got %v", out.Error)
+	}
+
+	fsys := fstest.MapFS{
+		"kep/README.md": {Data: []byte("---\ntitle: test\n---\n")},
+		"kep/kep.yaml":  {Data: []byte("owning-sig: sig-api-machinery\nexperimental-key: some value\n")},
+	}
+	out = p.ParseFile(fsys, "kep/README.md")
+	if out.Error == nil || out.Error.Error() != `kep.yaml: unknown key "experimental-key" at line 2 in KEP metadata` {
+		t.Fatalf("expected the kep.yaml key to be rejected but got %v", out.Error)
+	}
+
+	p.StrictYAML = false
+	if out := p.ParseFile(fsys, "kep/README.md"); out.Error != nil {
+		t.Fatalf("did not expect an error without StrictYAML: %v", out.Error)
+	}
+}
+
+func TestGroupBySIG(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "a", OwningSIG: "sig-node"},
+		{Title: "b", OwningSIG: "sig-storage"},
+		{Title: "c", OwningSIG: "sig-node"},
+	}
+	groups := proposals.GroupBySIG()
+	if len(groups) != 2 || len(groups["sig-storage"]) != 1 {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	node := groups["sig-node"]
+	if len(node) != 2 || node[0].Title != "a" || node[1].Title != "c" {
+		t.Fatalf("expected a and c for sig-node but got %v", node)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "a", Status: "implementable"},
+		{Title: "b", Status: "provisional"},
+		{Title: "c", Status: "implementable"},
+		{Title: "d"},
+	}
+	groups := proposals.GroupBy(func(p *keps.Proposal) string { return p.Status })
+	if len(groups) != 3 || len(groups[""]) != 1 || len(groups["provisional"]) != 1 {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	implementable := groups["implementable"]
+	if len(implementable) != 2 || implementable[0].Title != "a" || implementable[1].Title != "c" {
+		t.Fatalf("expected a and c to be implementable but got %v", implementable)
+	}
+}
+
+func TestMilestoneForStage(t *testing.T) {
+	p := &keps.Proposal{Milestone: map[string]string{"alpha": "v1.16", "Beta": "v1.17", "ga": "v1.19", "deprecated": ""}}
+	testcases := []struct {
+		stage     string
+		milestone string
+		ok        bool
+	}{
+		{"alpha", "v1.16", true},
+		{"beta", "v1.17", true},
+		{"stable", "v1.19", true},
+		{"GA", "v1.19", true},
+		{"deprecated", "", false},
+		{"unknown", "", false},
+	}
+	for _, tc := range testcases {
+		milestone, ok := p.MilestoneForStage(tc.stage)
+		if milestone != tc.milestone || ok != tc.ok {
+			t.Errorf("MilestoneForStage(%q): expected %q, %v but got %q, %v", tc.stage, tc.milestone, tc.ok, milestone, ok)
+		}
+	}
+	if _, ok := (&keps.Proposal{}).MilestoneForStage("alpha"); ok {
+		t.Error("expected no milestone without a milestone map")
+	}
+}
+
+func TestUpdatedExtremes(t *testing.T) {
+	if keps.Proposals(nil).LatestUpdated() != nil || (keps.Proposals{{Title: "undated"}}).OldestUpdated() != nil {
+		t.Fatal("expected no proposal without valid dates")
+	}
+	proposals := keps.Proposals{
+		{Title: "undated"},
+		{Title: "invalid", LastUpdated: "2020-13-01"},
+		{Title: "b", LastUpdated: "2019-05-01"},
+		{Title: "old", LastUpdated: "2018-01-02"},
+		{Title: "a", LastUpdated: "2019-05-01"},
+		{Title: "older", LastUpdated: "2018-01-01"},
+		{Title: "also older", LastUpdated: "2018-01-01"},
+	}
+	if latest := proposals.LatestUpdated(); latest == nil || latest.Title != "a" {
+		t.Errorf("expected the latest to be a but got %v", latest)
+	}
+	if oldest := proposals.OldestUpdated(); oldest == nil || oldest.Title != "also older" {
+		t.Errorf("expected the oldest to be also older but got %v", oldest)
+	}
+}
+
+func TestCountByKey(t *testing.T) {
+	parser := &keps.Parser{AllowedKeys: []string{"stage-notes"}}
+	var proposals keps.Proposals
+	for _, contents := range []string{
+		"---\ntitle: a\nowning-sig: sig-node\nstatus: provisional\n---\n",
+		"---\ntitle: b\nowning-sig: sig-node\nstage-notes: later\n---\n",
+	} {
+		kep := parser.Parse(strings.NewReader(contents))
+		if kep.Error != nil {
+			t.Fatalf("unexpected error: %v", kep.Error)
+		}
+		proposals.AddProposal(kep)
+	}
+	if keys := strings.Join(proposals[1].Keys(), ","); keys != "owning-sig,stage-notes,title" {
+		t.Errorf("unexpected keys %q", keys)
+	}
+	expected := map[string]int{"title": 2, "owning-sig": 2, "status": 1, "stage-notes": 1}
+	counts := proposals.CountByKey()
+	if len(counts) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, counts)
+	}
+	for key, count := range expected {
+		if counts[key] != count {
+			t.Errorf("expected %d for %q but got %d", count, key, counts[key])
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	proposals := keps.Proposals{{Title: "a"}, {Title: "b"}, {Title: "c"}}
+	stop := errors.New("stop")
+
+	var titles []string
+	err := proposals.Walk(func(p *keps.Proposal) error {
+		titles = append(titles, p.Title)
+		if p.Title == "b" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("expected the error from the callback but got %v", err)
+	}
+	if strings.Join(titles, ",") != "a,b" {
+		t.Fatalf("expected to stop after b but visited %v", titles)
+	}
+
+	titles = nil
+	if err := proposals.Walk(func(p *keps.Proposal) error {
+		titles = append(titles, p.Title)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(titles, ",") != "a,b,c" {
+		t.Fatalf("expected to visit every proposal but visited %v", titles)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "a", OwningSIG: "sig-node", Status: "provisional"},
+		{Title: "b", OwningSIG: "sig-node", Status: "implementable", FeatureGates: []keps.FeatureGate{{Name: "PodOverhead"}}},
+		{Title: "c", OwningSIG: "sig-storage", Status: "implementable", FeatureGates: []keps.FeatureGate{{Name: "CSIMigration"}, {Name: "CSIMigrationGCE"}}},
+	}
+	testcases := []struct {
+		name     string
+		filtered keps.Proposals
+		expected []string
+	}{
+		{
+			name:     "by status",
+			filtered: proposals.FilterByStatus("implementable"),
+			expected: []string{"b", "c"},
+		},
+		{
+			name:     "by several statuses",
+			filtered: proposals.FilterByStatus("implementable", "provisional"),
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "excluding a status",
+			filtered: proposals.ExcludeStatus("implementable"),
+			expected: []string{"a"},
+		},
+		{
+			name:     "excluding after including",
+			filtered: proposals.FilterByStatus("implementable", "provisional").ExcludeStatus("provisional", "rejected"),
+			expected: []string{"b", "c"},
+		},
+		{
+			name:     "by sig",
+			filtered: proposals.FilterBySIG("sig-storage"),
+			expected: []string{"c"},
+		},
+		{
+			name:     "by status and sig",
+			filtered: proposals.FilterByStatus("implementable").FilterBySIG("sig-node"),
+			expected: []string{"b"},
+		},
+		{
+			name:     "no match",
+			filtered: proposals.FilterBySIG("sig-apps"),
+			expected: []string{},
+		},
+		{
+			name:     "by feature gate",
+			filtered: proposals.FilterByFeatureGate("CSIMigrationGCE"),
+			expected: []string{"c"},
+		},
+		{
+			name:     "feature gate matches exactly",
+			filtered: proposals.FilterByFeatureGate("csimigration"),
+			expected: []string{},
+		},
+		{
+			name: "by predicate",
+			filtered: proposals.Filter(func(p *keps.Proposal) bool {
+				return p.Status == "implementable" || p.Title == "a"
+			}).Filter(func(p *keps.Proposal) bool {
+				return p.OwningSIG == "sig-node"
+			}),
+			expected: []string{"a", "b"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.filtered.Count() != len(tc.expected) {
+				t.Fatalf("expected %d proposals but got %d", len(tc.expected), tc.filtered.Count())
+			}
+			for i, title := range tc.expected {
+				if tc.filtered[i].Title != title {
+					t.Fatalf("expected %q but got %q", title, tc.filtered[i].Title)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFeatureGates(t *testing.T) {
+	contents := `---
+title: test
+authors:
+  - "@jpbetz"
+owning-sig: sig-api-machinery
+status: provisional
+feature-gates:
+  - name: ServerSideApply
+    components:
+      - kube-apiserver
+---
+`
+	parser := &keps.Parser{}
+	kep := parser.Parse(strings.NewReader(contents))
+	if kep.Error != nil {
+		t.Fatalf("unexpected error: %v", kep.Error)
+	}
+	if !kep.HasFeatureGate("ServerSideApply") {
+		t.Errorf("expected the ServerSideApply feature gate but got %v", kep.FeatureGates)
+	}
+	if kep.HasFeatureGate("ServerSide") {
+		t.Error("expected only exact feature gate names to match")
+	}
+	if len(kep.FeatureGates) != 1 || len(kep.FeatureGates[0].Components) != 1 || kep.FeatureGates[0].Components[0] != "kube-apiserver" {
+		t.Errorf("unexpected feature gates %+v", kep.FeatureGates)
+	}
+}
+
+func TestValidateRationale(t *testing.T) {
+	body := strings.Repeat("word ", keps.MinWordCount)
+	testcases := []struct {
+		name       string
+		status     string
+		contents   string
+		expectWarn bool
+	}{
+		{
+			name:     "provisional without rationale",
+			status:   "provisional",
+			contents: body,
+		},
+		{
+			name:       "rejected without rationale",
+			status:     "rejected",
+			contents:   body,
+			expectWarn: true,
+		},
+		{
+			name:     "rejected with rationale",
+			status:   "rejected",
+			contents: "## Rejection Reason\n" + body,
+		},
+		{
+			name:     "withdrawn with rationale in different case",
+			status:   "withdrawn",
+			contents: "## withdrawal\n" + body,
+		},
+		{
+			name:       "deferred with unrelated section",
+			status:     "deferred",
+			contents:   "## Summary\n" + body,
+			expectWarn: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.Status = tc.status
+			p.Contents = tc.contents
+			errs := p.Validate()
+			if tc.expectWarn && (len(errs) != 1 || !keps.IsWarning(errs[0])) {
+				t.Fatalf("expected a warning but got %v", errs)
+			}
+			if !tc.expectWarn && len(errs) != 0 {
+				t.Fatalf("did not expect an error: %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidatePRR(t *testing.T) {
+	body := strings.Repeat("word ", keps.MinWordCount)
+	testcases := []struct {
+		name       string
+		stage      string
+		milestone  map[string]string
+		contents   string
+		expectWarn bool
+	}{
+		{
+			name:     "alpha without questionnaire",
+			stage:    "alpha",
+			contents: body,
+		},
+		{
+			name:       "beta without questionnaire",
+			stage:      "beta",
+			contents:   body,
+			expectWarn: true,
+		},
+		{
+			name:       "stable milestone without questionnaire",
+			milestone:  map[string]string{"alpha": "v1.14", "stable": "v1.17"},
+			contents:   body,
+			expectWarn: true,
+		},
+		{
+			name:     "stable with questionnaire",
+			stage:    "stable",
+			contents: "## Production Readiness Review Questionnaire\n" + body,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.Stage = tc.stage
+			p.Milestone = tc.milestone
+			p.Contents = tc.contents
+			errs := p.Validate()
+			if tc.expectWarn && (len(errs) != 1 || !keps.IsWarning(errs[0])) {
+				t.Fatalf("expected a warning but got %v", errs)
+			}
+			if !tc.expectWarn && len(errs) != 0 {
+				t.Fatalf("did not expect an error: %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidateDisableSupported(t *testing.T) {
+	body := strings.Repeat("word ", keps.MinWordCount)
+	testcases := []struct {
+		name             string
+		disableSupported bool
+		contents         string
+		expectWarn       bool
+	}{
+		{
+			name:     "disable not supported",
+			contents: body,
+		},
+		{
+			name:             "disable supported without mention",
+			disableSupported: true,
+			contents:         body,
+			expectWarn:       true,
+		},
+		{
+			name:             "disable supported with rollback plan",
+			disableSupported: true,
+			contents:         "## Rollback\nThe feature gate can be turned off.\n" + body,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := validProposal()
+			p.DisableSupported = tc.disableSupported
+			p.Contents = tc.contents
+			errs := p.Validate()
+			if tc.expectWarn && (len(errs) != 1 || !keps.IsWarning(errs[0])) {
+				t.Fatalf("expected a warning but got %v", errs)
+			}
+			if !tc.expectWarn && len(errs) != 0 {
+				t.Fatalf("did not expect an error: %v", errs)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	base := func() *keps.Proposal {
+		return &keps.Proposal{
+			Title:     "test",
+			Authors:   []string{"@jpbetz"},
+			OwningSIG: "sig-api-machinery",
+			Status:    "provisional",
+			Contents:  "## Summary\n\nSome text.\n",
+		}
+	}
+	testcases := []struct {
+		name              string
+		modify            func(*keps.Proposal)
+		expectEqual       bool
+		expectNormalEqual bool
+	}{
+		{
+			name:              "equal",
+			modify:            func(p *keps.Proposal) {},
+			expectEqual:       true,
+			expectNormalEqual: true,
+		},
+		{
+			name:              "empty list equals missing list",
+			modify:            func(p *keps.Proposal) { p.SeeAlso = []string{} },
+			expectEqual:       true,
+			expectNormalEqual: true,
+		},
+		{
+			name:              "filename is ignored",
+			modify:            func(p *keps.Proposal) { p.Filename = "sig-api-machinery/test.md" },
+			expectEqual:       true,
+			expectNormalEqual: true,
+		},
+		{
+			name:   "field differs",
+			modify: func(p *keps.Proposal) { p.Status = "implementable" },
+		},
+		{
+			name:   "list differs",
+			modify: func(p *keps.Proposal) { p.Authors = append(p.Authors, "@sttts") },
+		},
+		{
+			name:   "body differs",
+			modify: func(p *keps.Proposal) { p.Contents = "## Summary\n\nOther text.\n" },
+		},
+		{
+			name:              "body whitespace differs",
+			modify:            func(p *keps.Proposal) { p.Contents = "## Summary\n\nSome\ntext.\n\n" },
+			expectNormalEqual: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := base(), base()
+			tc.modify(b)
+			if a.Equal(b) != tc.expectEqual {
+				t.Fatalf("expected Equal to be %v", tc.expectEqual)
+			}
+			if a.EqualNormalized(b) != tc.expectNormalEqual {
+				t.Fatalf("expected EqualNormalized to be %v", tc.expectNormalEqual)
+			}
+		})
+	}
+	nilcases := []struct {
+		name  string
+		a, b  *keps.Proposal
+		equal bool
+	}{
+		{name: "both nil", equal: true},
+		{name: "nil and proposal", b: base()},
+		{name: "proposal and nil", a: base()},
+	}
+	for _, tc := range nilcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.a.Equal(tc.b) != tc.equal {
+				t.Fatalf("expected Equal to be %v", tc.equal)
+			}
+			if tc.a.EqualNormalized(tc.b) != tc.equal {
+				t.Fatalf("expected EqualNormalized to be %v", tc.equal)
+			}
+		})
+	}
+}
+
+func TestValidateAuthors(t *testing.T) {
+	testcases := []struct {
+		name       string
+		status     string
+		authors    []string
+		severity   keps.Severity
+		expectWarn bool
+		expectErr  bool
+	}{
+		{
+			name:     "provisional with authors",
+			status:   "provisional",
+			authors:  []string{"@jpbetz"},
+			severity: keps.SeverityWarning,
+		},
+		{
+			name:       "implementable without authors",
+			status:     "implementable",
+			severity:   keps.SeverityWarning,
+			expectWarn: true,
+		},
+		{
+			name:      "implementable without authors as an error",
+			status:    "implementable",
+			severity:  keps.SeverityError,
+			expectErr: true,
+		},
+		{
+			name:     "rejected without authors",
+			status:   "rejected",
+			severity: keps.SeverityError,
+		},
+		{
+			name:     "replaced without authors",
+			status:   "replaced",
+			severity: keps.SeverityError,
+		},
+	}
+	defer func(severity keps.Severity) { keps.AuthorsSeverity = severity }(keps.AuthorsSeverity)
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			keps.AuthorsSeverity = tc.severity
+			p := validProposal()
+			p.Status = tc.status
+			p.Authors = tc.authors
+			// terminal statuses need a rationale
+			p.Contents = "## Rationale\n" + p.Contents
+			var warned, failed bool
+			for _, err := range p.Validate() {
+				switch {
+				case !strings.Contains(err.Error(), `"authors"`):
+					if !keps.IsWarning(err) {
+						t.Fatalf("did not expect an error: %v", err)
+					}
+				case keps.IsWarning(err):
+					warned = true
+				default:
+					failed = true
+				}
+			}
+			if warned != tc.expectWarn || failed != tc.expectErr {
+				t.Fatalf("expected a warning %v and an error %v about the authors but got %v", tc.expectWarn, tc.expectErr, p.Validate())
+			}
+		})
+	}
+}
+
+func TestValidateImplementableReviewers(t *testing.T) {
+	testcases := []struct {
+		name       string
+		status     string
+		reviewers  []string
+		severity   keps.Severity
+		expectWarn bool
+		expectErr  bool
+	}{
+		{
+			name:     "provisional without reviewers",
+			status:   "provisional",
+			severity: keps.SeverityWarning,
+		},
+		{
+			name:      "implementable with reviewers",
+			status:    "implementable",
+			reviewers: []string{"@liggitt"},
+			severity:  keps.SeverityWarning,
+		},
+		{
+			name:       "implementable without reviewers",
+			status:     "implementable",
+			severity:   keps.SeverityWarning,
+			expectWarn: true,
+		},
+		{
+			name:      "implementable without reviewers as an error",
+			status:    "implementable",
+			severity:  keps.SeverityError,
+			expectErr: true,
+		},
+	}
+	defer func(severity keps.Severity) { keps.ImplementableReviewersSeverity = severity }(keps.ImplementableReviewersSeverity)
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			keps.ImplementableReviewersSeverity = tc.severity
+			p := validProposal()
+			p.Status = tc.status
+			p.Reviewers = tc.reviewers
+			errs := p.Validate()
+			if !tc.expectWarn && !tc.expectErr {
+				if len(errs) != 0 {
+					t.Fatalf("did not expect an error: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || keps.IsWarning(errs[0]) != tc.expectWarn {
+				t.Fatalf("expected one problem with warning %v but got %v", tc.expectWarn, errs)
+			}
+		})
+	}
+}
+
+func TestValidateStrictMilestone(t *testing.T) {
+	testcases := []struct {
+		name            string
+		strict          bool
+		status          string
+		latestMilestone string
+		milestone       map[string]string
+		severity        keps.Severity
+		expectWarn      bool
+		expectErr       bool
+	}{
+		{
+			name:     "implementable without milestone when not strict",
+			status:   "implementable",
+			severity: keps.SeverityWarning,
+		},
+		{
+			name:     "provisional without milestone",
+			strict:   true,
+			status:   "provisional",
+			severity: keps.SeverityWarning,
+		},
+		{
+			name:            "implementable with latest-milestone",
+			strict:          true,
+			status:          "implementable",
+			latestMilestone: "v1.19",
+			severity:        keps.SeverityWarning,
+		},
+		{
+			name:      "implemented with milestone",
+			strict:    true,
+			status:    "implemented",
+			milestone: map[string]string{"alpha": "v1.18"},
+			severity:  keps.SeverityWarning,
+		},
+		{
+			name:       "implementable without milestone",
+			strict:     true,
+			status:     "implementable",
+			milestone:  map[string]string{"alpha": ""},
+			severity:   keps.SeverityWarning,
+			expectWarn: true,
+		},
+		{
+			name:      "implemented without milestone as an error",
+			strict:    true,
+			status:    "implemented",
+			severity:  keps.SeverityError,
+			expectErr: true,
+		},
+	}
+	defer func(strict bool, severity keps.Severity) {
+		keps.StrictMilestone, keps.StrictMilestoneSeverity = strict, severity
+	}(keps.StrictMilestone, keps.StrictMilestoneSeverity)
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			keps.StrictMilestone, keps.StrictMilestoneSeverity = tc.strict, tc.severity
+			p := validProposal()
+			p.Status = tc.status
+			p.Reviewers = []string{"@liggitt"}
+			p.LatestMilestone = tc.latestMilestone
+			p.Milestone = tc.milestone
+			errs := p.Validate()
+			if !tc.expectWarn && !tc.expectErr {
+				if len(errs) != 0 {
+					t.Fatalf("did not expect an error: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || keps.IsWarning(errs[0]) != tc.expectWarn {
+				t.Fatalf("expected one problem with warning %v but got %v", tc.expectWarn, errs)
+			}
+			expected := tc.status + ` KEPs must record the release they target in "latest-milestone" or "milestone"`
+			if errs[0].Error() != expected {
+				t.Fatalf("expected %q but got %q", expected, errs[0])
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	const metadata = `title: test
+authors:
+  - "@jpbetz"
+owning-sig: sig-api-machinery
+status: implementable
+`
+	fsys := fstest.MapFS{
+		"frontmatter/0001-kep.md": {Data: []byte("---\n" + metadata + "---\n# Body\n")},
+		"split/README.md":         {Data: []byte("# Body\n")},
+		"split/kep.yaml":          {Data: []byte(metadata)},
+		"both/README.md":          {Data: []byte("---\ntitle: old title\nowning-sig: sig-api-machinery\neditor: \"@sttts\"\n---\n# Body\n")},
+		"both/kep.yaml":           {Data: []byte(metadata)},
+		"missing/README.md":       {Data: []byte("# Body\n")},
+		"no-body/kep.yaml":        {Data: []byte(metadata)},
+		"tabs/README.md":          {Data: []byte("# Body\n")},
+		"tabs/kep.yaml":           {Data: []byte("title: test\nauthors:\n\t- \"@jpbetz\"\n")},
+		"unterminated/README.md":  {Data: []byte("---\ntitle: test\n# Body\n")},
+		"unterminated/kep.yaml":   {Data: []byte(metadata)},
+	}
+	testcases := []struct {
+		name          string
+		file          string
+		expectedTitle string
+		expectedError string
+		expectWarn    bool
+	}{
+		{
+			name:          "frontmatter only",
+			file:          "frontmatter/0001-kep.md",
+			expectedTitle: "test",
+		},
+		{
+			name:          "kep.yaml only",
+			file:          "split/README.md",
+			expectedTitle: "test",
+		},
+		{
+			name:          "kep.yaml wins over the frontmatter",
+			file:          "both/README.md",
+			expectedTitle: "test",
+			expectWarn:    true,
+		},
+		{
+			name:          "no metadata at all",
+			file:          "missing/README.md",
+			expectedError: "missing/README.md has no frontmatter and there is no kep.yaml next to it",
+		},
+		{
+			name:          "kep.yaml without README.md",
+			file:          "no-body/README.md",
+			expectedError: "error reading file: open no-body/README.md: file does not exist",
+		},
+		{
+			name:          "tab in kep.yaml",
+			file:          "tabs/README.md",
+			expectedError: "tabs/kep.yaml: tab character in YAML indentation at line 3; use spaces",
+		},
+		{
+			name:          "broken frontmatter next to kep.yaml",
+			file:          "unterminated/README.md",
+			expectedError: "unterminated frontmatter block starting at line 1",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &keps.Parser{}
+			kep := p.ParseFile(fsys, tc.file)
+			if tc.expectedError != "" {
+				if kep.Error == nil || kep.Error.Error() != tc.expectedError {
+					t.Fatalf("expected error %q but got %v", tc.expectedError, kep.Error)
+				}
+				return
+			}
+			if kep.Error != nil {
+				t.Fatalf("unexpected error: %v", kep.Error)
+			}
+			if kep.Title != tc.expectedTitle || kep.Contents != "# Body\n" {
+				t.Fatalf("unexpected proposal %+v", kep)
+			}
+			conflict := false
+			for _, err := range kep.Warnings {
+				if strings.Contains(err.Error(), `"title" has different values`) {
+					conflict = true
+				}
+			}
+			if conflict != tc.expectWarn {
+				t.Fatalf("expected a conflict warning to be %v but got %v", tc.expectWarn, kep.Warnings)
+			}
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	const frontmatter = `---
+title: Benchmark KEP
+authors:
+  - "@jpbetz"
+owning-sig: sig-api-machinery
+participating-sigs:
+  - sig-architecture
+reviewers:
+  - "@deads2k"
+approvers:
+  - "@lavalamp"
+creation-date: 2019-01-01
+last-updated: 2019-02-01
+status: implementable
+---
+`
+	section := "## Section\n\nSome text with a [link](#section) and `code`.\n\n- [ ] item\n\n"
+	testcases := []struct {
+		name     string
+		sections int
+	}{
+		{"small", 10},
+		{"medium", 200},
+		{"large", 5000},
+	}
+	for _, tc := range testcases {
+		contents := frontmatter + "# Benchmark KEP\n\n" + strings.Repeat(section, tc.sections)
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(contents)))
+			parser := &keps.Parser{}
+			for i := 0; i < b.N; i++ {
+				if kep := parser.Parse(strings.NewReader(contents)); kep.Error != nil {
+					b.Fatal(kep.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateKEPNumber(t *testing.T) {
+	testcases := []struct {
+		name      string
+		contents  string
+		expectErr bool
+	}{
+		{"no kep-number", "", false},
+		{"valid kep-number", "kep-number: 4\n", false},
+		{"highest kep-number", "kep-number: 9999\n", false},
+		{"zero kep-number", "kep-number: 0\n", true},
+		{"negative kep-number", "kep-number: -3\n", true},
+		{"kep-number out of range", "kep-number: 123456\n", true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := (&keps.Parser{}).Parse(strings.NewReader("---\n" + tc.contents + "title: test\nowning-sig: sig-api-machinery\n---\n"))
+			if p.Error != nil {
+				t.Fatalf("unexpected error: %v", p.Error)
+			}
+			var failures []error
+			for _, err := range p.Validate() {
+				if strings.Contains(err.Error(), "kep-number") {
+					failures = append(failures, err)
+				}
+			}
+			if tc.expectErr != (len(failures) == 1) || len(failures) > 1 {
+				t.Fatalf("expected an error: %v, got %v", tc.expectErr, failures)
+			}
+			if tc.expectErr && keps.IsWarning(failures[0]) {
+				t.Fatalf("expected an error but got a warning: %v", failures[0])
+			}
+		})
+	}
+}
+
+func TestParseFileReadError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"prose/README.md": {Data: []byte("# Title\n")},
+	}
+	if kep := (&keps.Parser{}).ParseFile(fsys, "missing/README.md"); !keps.IsReadError(kep.Error) {
+		t.Errorf("expected a read error but got %v", kep.Error)
+	}
+	if kep := (&keps.Parser{}).ParseFile(fsys, "prose/README.md"); kep.Error == nil || keps.IsReadError(kep.Error) {
+		t.Errorf("expected an error that is not a read error but got %v", kep.Error)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/enhancements/pkg/kepval/keps/validations"
+)
+
+var (
+	// reKEPRef matches references by number such as KEP-15 or KEP 0008
+	reKEPRef = regexp.MustCompile(`(?i)^KEP[- ]?(\d+)$`)
+	// reKEPFileNumber matches the number of a numbered KEP file or
+	// directory name, such as 0015-dry-run.md
+	reKEPFileNumber = regexp.MustCompile(`^(\d{1,4})-`)
+)
+
+// Resolve returns the proposal that ref, an entry of see-also, replaces or
+// superseded-by, refers to, or nil if it does not refer to any of p. The
+// Filename of each proposal must be its path relative to the KEP directory.
+// References may be a path with or without a leading /keps/, a file name
+// with or without the .md extension, or a KEP number such as KEP-15.
+func (p Proposals) Resolve(ref string) *Proposal {
+	ref = strings.TrimSpace(ref)
+	if match := reKEPRef.FindStringSubmatch(ref); match != nil {
+		number, _ := strconv.Atoi(match[1])
+		for _, proposal := range p {
+			if n, ok := proposal.Number(); ok && n == number {
+				return proposal
+			}
+		}
+		return nil
+	}
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "/"), "keps/")
+	if !strings.HasSuffix(ref, ".md") {
+		ref += ".md"
+	}
+	for _, proposal := range p {
+		if proposal.Filename == ref {
+			return proposal
+		}
+	}
+	// bare file names are only resolved when they are not ambiguous
+	if strings.Contains(ref, "/") {
+		return nil
+	}
+	var found *Proposal
+	for _, proposal := range p {
+		if path.Base(proposal.Filename) == ref {
+			if found != nil {
+				return nil
+			}
+			found = proposal
+		}
+	}
+	return found
+}
+
+// Number returns the number of a numbered KEP, taken from its Filename,
+// see PathNumber.
+func (p *Proposal) Number() (int, bool) {
+	return PathNumber(p.Filename)
+}
+
+// PathNumber returns the number of the numbered KEP at name, a slash
+// separated path. It is taken from the file name, such as
+// 0015-dry-run.md, or for a KEP directory from the name of the directory
+// that holds its BodyFile, such as 1234-foo/README.md. ok is false for
+// paths that are not numbered.
+func PathNumber(name string) (number int, ok bool) {
+	base := path.Base(name)
+	if base == BodyFile {
+		base = path.Base(path.Dir(name))
+	}
+	match := reKEPFileNumber.FindStringSubmatch(base)
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(match[1])
+	return n, err == nil
+}
+
+// ValidateNumberPadding reports every proposal whose Filename has a KEP
+// number prefix with fewer than NumberWidth digits, suggesting the padded
+// name. The problems are warnings unless Strict is set.
+func (p Proposals) ValidateNumberPadding() []error {
+	var errs []error
+	for _, proposal := range p {
+		if err := validations.ValidateNumberPadding(proposal.Filename, NumberWidth); err != nil {
+			if !Strict {
+				err = &Warning{err}
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// ValidateReplacementCycles reports every cycle in the graph of proposals
+// that replace each other. An edge from A to B is either listed in the
+// replaces of A or A is listed in the superseded-by of B. References that
+// do not resolve are ignored.
+func (p Proposals) ValidateReplacementCycles() []error {
+	return p.ValidateReplacementCyclesIn(p)
+}
+
+// ValidateReplacementCyclesIn is like ValidateReplacementCycles, but the
+// references are resolved against tree, which holds p along with the other
+// KEPs, and only the cycles that one of p is part of are reported.
+func (p Proposals) ValidateReplacementCyclesIn(tree Proposals) []error {
+	selected := p.set()
+	edges := map[*Proposal][]*Proposal{}
+	addEdge := func(from, to *Proposal) {
+		for _, existing := range edges[from] {
+			if existing == to {
+				return
+			}
+		}
+		edges[from] = append(edges[from], to)
+	}
+	for _, proposal := range tree {
+		for _, ref := range proposal.Replaces {
+			if replaced := tree.Resolve(ref); replaced != nil {
+				addEdge(proposal, replaced)
+			}
+		}
+		for _, ref := range proposal.SupersededBy {
+			if successor := tree.Resolve(ref); successor != nil {
+				addEdge(successor, proposal)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[*Proposal]int{}
+	var stack []*Proposal
+	var errs []error
+	var visit func(*Proposal)
+	visit = func(proposal *Proposal) {
+		state[proposal] = visiting
+		stack = append(stack, proposal)
+		for _, next := range edges[proposal] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case visiting:
+				var cycle []string
+				involved := false
+				for i := len(stack) - 1; i >= 0; i-- {
+					cycle = append([]string{stack[i].Filename}, cycle...)
+					involved = involved || selected[stack[i]]
+					if stack[i] == next {
+						break
+					}
+				}
+				if involved {
+					errs = append(errs, validations.ValidateNoCycle("replaces", append(cycle, next.Filename)))
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[proposal] = done
+	}
+	for _, proposal := range tree {
+		if state[proposal] == unvisited {
+			visit(proposal)
+		}
+	}
+	return errs
+}
+
+// set returns the proposals of p as a set.
+func (p Proposals) set() map[*Proposal]bool {
+	set := make(map[*Proposal]bool, len(p))
+	for _, proposal := range p {
+		set[proposal] = true
+	}
+	return set
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestResolve(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "apply", Filename: "sig-api-machinery/0006-apply.md"},
+		{Title: "dry run", Filename: "sig-api-machinery/0015-dry-run.md"},
+		{Title: "metrics", Filename: "sig-instrumentation/20181106-kubernetes-metrics-overhaul.md"},
+		{Title: "readme a", Filename: "sig-a/README.md"},
+		{Title: "readme b", Filename: "sig-b/README.md"},
+		{Title: "split", Filename: "sig-node/1234-split-metadata/README.md"},
+	}
+	testcases := []struct {
+		ref      string
+		expected string
+	}{
+		{"/keps/sig-api-machinery/0006-apply.md", "apply"},
+		{"keps/sig-api-machinery/0006-apply.md", "apply"},
+		{"sig-api-machinery/0015-dry-run.md", "dry run"},
+		{"20181106-kubernetes-metrics-overhaul", "metrics"},
+		{"0015-dry-run.md", "dry run"},
+		{"KEP-15", "dry run"},
+		{"KEP 0006", "apply"},
+		{"README.md", ""},
+		{"n/a", ""},
+		{"KEP-100", ""},
+		{"KEP-1234", "split"},
+		{"sig-node/1234-split-metadata/README.md", "split"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.ref, func(t *testing.T) {
+			resolved := proposals.Resolve(tc.ref)
+			if tc.expected == "" {
+				if resolved != nil {
+					t.Fatalf("expected no proposal but got %q", resolved.Title)
+				}
+				return
+			}
+			if resolved == nil || resolved.Title != tc.expected {
+				t.Fatalf("expected %q but got %v", tc.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestPathNumber(t *testing.T) {
+	testcases := []struct {
+		name     string
+		number   int
+		numbered bool
+	}{
+		{"sig-api-machinery/0015-dry-run.md", 15, true},
+		{"sig-node/1234-split-metadata/README.md", 1234, true},
+		{"sig-node/1234-split-metadata/design.md", 0, false},
+		{"sig-instrumentation/20181106-kubernetes-metrics-overhaul.md", 0, false},
+		{"sig-a/README.md", 0, false},
+		{"README.md", 0, false},
+	}
+	for _, tc := range testcases {
+		number, ok := keps.PathNumber(tc.name)
+		if number != tc.number || ok != tc.numbered {
+			t.Errorf("PathNumber(%q): expected %d, %v but got %d, %v", tc.name, tc.number, tc.numbered, number, ok)
+		}
+	}
+}
+
+func TestValidateReplacementCycles(t *testing.T) {
+	testcases := []struct {
+		name      string
+		proposals keps.Proposals
+		expected  []string
+	}{
+		{
+			name: "replacement chain",
+			proposals: keps.Proposals{
+				{Filename: "a.md", Replaces: []string{"b.md"}},
+				{Filename: "b.md", Replaces: []string{"c.md"}, SupersededBy: []string{"a.md"}},
+				{Filename: "c.md", SupersededBy: []string{"b.md"}},
+			},
+		},
+		{
+			name: "replacing each other",
+			proposals: keps.Proposals{
+				{Filename: "a.md", Replaces: []string{"b.md"}},
+				{Filename: "b.md", Replaces: []string{"a.md"}},
+			},
+			expected: []string{"a.md -> b.md -> a.md"},
+		},
+		{
+			name: "cycle through superseded-by",
+			proposals: keps.Proposals{
+				{Filename: "a.md", Replaces: []string{"b.md"}},
+				{Filename: "b.md", Replaces: []string{"c.md"}},
+				{Filename: "c.md", SupersededBy: []string{"b.md"}, Replaces: []string{"n/a"}},
+				{Filename: "d.md", SupersededBy: []string{"c.md"}, Replaces: []string{"a.md"}},
+			},
+			expected: []string{"a.md -> b.md -> c.md -> d.md -> a.md"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := tc.proposals.ValidateReplacementCycles()
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("expected %d cycles but got %v", len(tc.expected), errs)
+			}
+			for i, cycle := range tc.expected {
+				if !strings.Contains(errs[i].Error(), cycle) {
+					t.Errorf("expected cycle %q but got %q", cycle, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateReplacementCyclesIn(t *testing.T) {
+	tree := keps.Proposals{
+		{Filename: "a.md", Replaces: []string{"b.md"}},
+		{Filename: "b.md", Replaces: []string{"a.md"}},
+		{Filename: "c.md", Replaces: []string{"d.md"}},
+		{Filename: "d.md", Replaces: []string{"c.md"}},
+		{Filename: "e.md"},
+	}
+	if errs := tree[:1].ValidateReplacementCycles(); len(errs) != 0 {
+		t.Fatalf("expected no cycle without the rest of the tree but got %v", errs)
+	}
+	errs := tree[:1].ValidateReplacementCyclesIn(tree)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "a.md -> b.md -> a.md") {
+		t.Fatalf("expected only the cycle through a.md but got %v", errs)
+	}
+	if errs := tree[4:].ValidateReplacementCyclesIn(tree); len(errs) != 0 {
+		t.Fatalf("expected the cycles of other KEPs to be left out but got %v", errs)
+	}
+}
+
+func TestValidateNumberPadding(t *testing.T) {
+	defer func() { keps.NumberWidth, keps.Strict = 4, false }()
+	proposals := keps.Proposals{
+		{Filename: "sig-api-machinery/0015-dry-run.md"},
+		{Filename: "sig-api-machinery/34-storage-hash.md"},
+		{Filename: "sig-apps/20180925-optional-env.md"},
+		{Filename: "sig-node/7-sandbox/README.md"},
+		{Filename: "sig-node/runtime-class.md"},
+	}
+	expected := []string{
+		"rename it to sig-api-machinery/0034-storage-hash.md",
+		"rename it to sig-node/0007-sandbox/README.md",
+	}
+	errs := proposals.ValidateNumberPadding()
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d problems but got %v", len(expected), errs)
+	}
+	for i, rename := range expected {
+		if !keps.IsWarning(errs[i]) || !strings.Contains(errs[i].Error(), rename) {
+			t.Errorf("expected a warning to %s but got %q", rename, errs[i])
+		}
+	}
+
+	keps.NumberWidth, keps.Strict = 2, true
+	errs = proposals.ValidateNumberPadding()
+	if len(errs) != 1 || keps.IsWarning(errs[0]) || !strings.Contains(errs[0].Error(), "sig-node/07-sandbox/README.md") {
+		t.Fatalf("expected one error for the sandbox KEP but got %v", errs)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ValidatorFunc checks a proposal and returns every problem it finds.
+// Problems that should be reported without failing validation must be
+// returned as a *Warning. A ValidatorFunc must not modify the proposal.
+type ValidatorFunc func(*Proposal) []error
+
+type validator struct {
+	name string
+	fn   ValidatorFunc
+}
+
+var (
+	// validators in the order they were registered, which is the order
+	// Validate runs them in
+	validators []validator
+	// enabled is the set of validators Validate runs, or nil for all of
+	// them
+	enabled map[string]bool
+)
+
+// RegisterValidator adds a validator that Validate runs on every proposal.
+// It is meant to be called from init functions, so that tools can add their
+// own rules on top of the built in ones. It panics if name is already
+// registered.
+func RegisterValidator(name string, fn ValidatorFunc) {
+	for _, v := range validators {
+		if v.name == name {
+			panic(fmt.Sprintf("keps: validator %q registered twice", name))
+		}
+	}
+	validators = append(validators, validator{name, fn})
+}
+
+// Validators returns the names of all registered validators in the order
+// they run.
+func Validators() []string {
+	names := make([]string, len(validators))
+	for i, v := range validators {
+		names[i] = v.name
+	}
+	return names
+}
+
+// EnableValidators restricts Validate to only run the named validators.
+// Calling it without any names runs all registered validators again.
+func EnableValidators(names ...string) error {
+	if len(names) == 0 {
+		enabled = nil
+		return nil
+	}
+	set := map[string]bool{}
+	for _, name := range names {
+		found := false
+		for _, v := range validators {
+			if v.name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("unknown validator %q", name)
+		}
+		set[name] = true
+	}
+	enabled = set
+	return nil
+}
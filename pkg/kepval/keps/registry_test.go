@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+var errNoTestTitle = errors.New("title must not be custom")
+
+func init() {
+	keps.RegisterValidator("test-custom", func(p *keps.Proposal) []error {
+		if p.Title == "custom" {
+			return []error{errNoTestTitle}
+		}
+		return nil
+	})
+}
+
+func TestRegisterValidator(t *testing.T) {
+	defer keps.EnableValidators()
+
+	testcases := []struct {
+		name    string
+		enable  []string
+		title   string
+		authors []string
+		wantErr error
+		wantLen int
+	}{
+		{"custom validator runs by default", nil, "custom", []string{"@jpbetz"}, errNoTestTitle, 1},
+		{"custom validator passes", nil, "test", []string{"@jpbetz"}, nil, 0},
+		{"only custom validator enabled", []string{"test-custom"}, "custom", nil, errNoTestTitle, 1},
+		{"custom validator disabled", []string{"authors"}, "custom", []string{"@jpbetz"}, nil, 0},
+		{"built in validator enabled", []string{"authors"}, "test", nil, nil, 1},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := keps.EnableValidators(tc.enable...); err != nil {
+				t.Fatal(err)
+			}
+			p := validProposal()
+			p.Title = tc.title
+			p.Authors = tc.authors
+			errs := p.Validate()
+			if len(errs) != tc.wantLen {
+				t.Fatalf("expected %d errors but got %v", tc.wantLen, errs)
+			}
+			if tc.wantErr != nil && errs[0] != tc.wantErr {
+				t.Errorf("expected %v but got %v", tc.wantErr, errs[0])
+			}
+		})
+	}
+}
+
+func TestEnableUnknownValidator(t *testing.T) {
+	defer keps.EnableValidators()
+	if err := keps.EnableValidators("authors", "no-such-validator"); err == nil {
+		t.Error("expected an error for an unknown validator")
+	}
+}
+
+func TestRegisterDuplicateValidator(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a duplicate validator to panic")
+		}
+	}()
+	keps.RegisterValidator("authors", func(*keps.Proposal) []error { return nil })
+}
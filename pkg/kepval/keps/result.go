@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"io/fs"
+
+	"github.com/pkg/errors"
+)
+
+// FieldError is a problem found in a KEP along with where it was found.
+type FieldError struct {
+	// Field is the top-level metadata key the problem is about, such as
+	// owning-sig. It is empty for problems that are not about a single key,
+	// such as those of the body.
+	Field string
+	// Line is the line number of the problem in the KEP file, or 0 if it is
+	// not known.
+	Line     int
+	Message  string
+	Severity Severity
+
+	err error
+}
+
+func (f FieldError) Error() string {
+	return f.Message
+}
+
+// Unwrap returns the error the problem was found as, such as a *ReadError
+// or one of the errors of the validations package.
+func (f FieldError) Unwrap() error {
+	return f.err
+}
+
+// newFieldError returns err as a FieldError with the given severity. The
+// field and line are taken from the Key and Line methods of the errors of
+// the validations package, once err is unwrapped.
+func newFieldError(err error, severity Severity) FieldError {
+	if warning, ok := err.(*Warning); ok {
+		err = warning.Err
+	}
+	problem := FieldError{Message: err.Error(), Severity: severity, err: err}
+	cause := errors.Cause(err)
+	if keyed, ok := cause.(interface{ Key() string }); ok {
+		problem.Field = keyed.Key()
+	}
+	if lined, ok := cause.(interface{ Line() int }); ok {
+		problem.Line = lined.Line()
+	}
+	return problem
+}
+
+// ParseResult is a parsed KEP along with every problem found in it, see
+// Parser.ParseFileResult.
+type ParseResult struct {
+	// Proposal is the parsed KEP. Its Error and Warnings are also part of
+	// Errors and Warnings.
+	Proposal *Proposal
+	// Errors are the problems that make the KEP invalid. A KEP that does not
+	// parse only has the parse error, since it cannot be validated.
+	Errors []FieldError
+	// Warnings are the problems that should be fixed but do not make the KEP
+	// invalid.
+	Warnings []FieldError
+	// Path is the name of the KEP file the result is for.
+	Path string
+}
+
+// ParseFileResult parses the KEP called name in fsys like ParseFile and
+// returns it as a result, see NewParseResult. Parse, ParseFile and
+// Proposal.Error keep working as they are, so callers can move to
+// ParseFileResult one at a time.
+func (p *Parser) ParseFileResult(fsys fs.FS, name string) *ParseResult {
+	return NewParseResult(p.ParseFile(fsys, name), name)
+}
+
+// NewParseResult returns the problems of a parsed proposal read from path
+// and, if it parsed, validates it like Validate. Every problem is returned
+// as a FieldError, the warnings found while parsing before those of
+// Validate. It can be called again once the proposal has been changed, such
+// as by a fix.
+func NewParseResult(proposal *Proposal, path string) *ParseResult {
+	result := &ParseResult{Proposal: proposal, Path: path}
+	for _, err := range result.Proposal.Warnings {
+		result.Warnings = append(result.Warnings, newFieldError(err, SeverityWarning))
+	}
+	if err := result.Proposal.Error; err != nil {
+		result.Errors = append(result.Errors, newFieldError(err, SeverityError))
+		return result
+	}
+	for _, err := range result.Proposal.Validate() {
+		if IsWarning(err) {
+			result.Warnings = append(result.Warnings, newFieldError(err, SeverityWarning))
+		} else {
+			result.Errors = append(result.Errors, newFieldError(err, SeverityError))
+		}
+	}
+	return result
+}
+
+// Parsed reports whether the KEP parsed, in which case its Errors are the
+// problems found by Validate.
+func (r *ParseResult) Parsed() bool {
+	return r.Proposal.Error == nil
+}
+
+// Unreadable reports whether the KEP could not be read at all, see
+// ReadError.
+func (r *ParseResult) Unreadable() bool {
+	return IsReadError(r.Proposal.Error)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestParseFileResult(t *testing.T) {
+	defer func(minWords int) { keps.MinWordCount = minWords }(keps.MinWordCount)
+	keps.MinWordCount = 0
+
+	const metadata = "title: test\nauthors:\n  - \"@jpbetz\"\nowning-sig: sig-api-machinery\nstatus: provisional\n"
+	fsys := fstest.MapFS{
+		"valid/0001-kep.md":    {Data: []byte("---\n" + metadata + "---\n# Body\n")},
+		"problems/0002-kep.md": {Data: []byte("---\n" + metadata + "participating-sigs:\n  - sig-cli\n  - sig-cli\n---\n# Body\n### Details\n")},
+		"both/README.md":       {Data: []byte("---\ntitle: old title\nowning-sig: sig-api-machinery\n---\n# Body\n")},
+		"both/kep.yaml":        {Data: []byte(metadata)},
+		"invalid/0003-kep.md":  {Data: []byte("---\ntitle: test\nowning-sig: sig-api-machinery\nstatus: done\n---\n# Body\n### Details\n")},
+		"tabs/0004-kep.md":     {Data: []byte("---\ntitle: test\nauthors:\n\t- \"@jpbetz\"\n---\n# Body\n")},
+		"extra/0005-kep.md":    {Data: []byte("---\ntitle: test\n---\nowning-sig: sig-api-machinery\n---\n# Body\n")},
+		"unknown/0006-kep.md":  {Data: []byte("---\n" + metadata + "owner: sig-api-machinery\n---\n# Body\n")},
+	}
+	testcases := []struct {
+		file     string
+		strict   bool
+		errors   []keps.FieldError
+		warnings []keps.FieldError
+	}{
+		{
+			file: "valid/0001-kep.md",
+		},
+		{
+			file:     "problems/0002-kep.md",
+			errors:   []keps.FieldError{{Field: "participating-sigs", Message: `"participating-sigs" must not contain duplicates but "sig-cli" is listed more than once`, Severity: keps.SeverityError}},
+			warnings: []keps.FieldError{{Line: 12, Message: "line 12 has a level 3 heading directly below a level 1 heading", Severity: keps.SeverityWarning}},
+		},
+		{
+			file:     "both/README.md",
+			warnings: []keps.FieldError{{Field: "title", Message: `"title" has different values in the frontmatter and in kep.yaml, using the one from kep.yaml`, Severity: keps.SeverityWarning}},
+		},
+		{
+			// the heading is not checked once the metadata is invalid
+			file:   "invalid/0003-kep.md",
+			errors: []keps.FieldError{{Field: "status", Message: `error validating KEP metadata: "status" must be one of (provisional,implementable,implemented,deferred,rejected,withdrawn,replaced) but it is a string: done`, Severity: keps.SeverityError}},
+		},
+		{
+			file:   "tabs/0004-kep.md",
+			errors: []keps.FieldError{{Line: 4, Message: "tab character in YAML indentation at line 4; use spaces", Severity: keps.SeverityError}},
+		},
+		{
+			file:   "extra/0005-kep.md",
+			errors: []keps.FieldError{{Line: 3, Message: "extra --- at line 3 inside the frontmatter block starting at line 1; the metadata must be a single YAML document", Severity: keps.SeverityError}},
+		},
+		{
+			file:   "unknown/0006-kep.md",
+			strict: true,
+			errors: []keps.FieldError{{Field: "owner", Line: 7, Message: `unknown key "owner" at line 7 in KEP metadata`, Severity: keps.SeverityError}},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.file, func(t *testing.T) {
+			p := &keps.Parser{StrictYAML: tc.strict}
+			result := p.ParseFileResult(fsys, tc.file)
+			if result.Path != tc.file || result.Proposal == nil {
+				t.Fatalf("expected the result for %s but got %+v", tc.file, result)
+			}
+			compareFieldErrors(t, "error", tc.errors, result.Errors)
+			compareFieldErrors(t, "warning", tc.warnings, result.Warnings)
+		})
+	}
+}
+
+func compareFieldErrors(t *testing.T, kind string, expected, actual []keps.FieldError) {
+	t.Helper()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d %ss but got %+v", len(expected), kind, actual)
+	}
+	for i, problem := range actual {
+		e := expected[i]
+		if problem.Field != e.Field || problem.Line != e.Line || problem.Message != e.Message || problem.Severity != e.Severity {
+			t.Errorf("expected the %s %+v but got %+v", kind, e, problem)
+		}
+	}
+}
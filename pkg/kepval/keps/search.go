@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// SearchRecord is the entry of a KEP in a search index, meant to be loaded
+// by client side search libraries such as Lunr or Fuse.
+type SearchRecord struct {
+	// Number is nil for KEPs whose file name is not numbered.
+	Number  *int   `json:"number"`
+	Title   string `json:"title"`
+	SIG     string `json:"sig"`
+	Status  string `json:"status"`
+	Summary string `json:"summary"`
+	// URL is the Filename of the proposal.
+	URL  string `json:"url"`
+	Body string `json:"body"`
+}
+
+var (
+	reListMarker = regexp.MustCompile(`^\s*(?:[-*+>]|\d+\.)\s+`)
+	reEmphasis   = regexp.MustCompile("\\*\\*|__|`")
+)
+
+// SearchRecord returns the entry of the proposal in a search index. If
+// plainText is set, the body is reduced to plain text with PlainText.
+func (p *Proposal) SearchRecord(plainText bool) SearchRecord {
+	record := SearchRecord{
+		Title:   p.Title,
+		SIG:     p.OwningSIG,
+		Status:  p.Status,
+		Summary: StripLinks(p.Summary()),
+		URL:     p.Filename,
+		Body:    p.Contents,
+	}
+	if n, ok := p.Number(); ok {
+		record.Number = &n
+	}
+	if plainText {
+		record.Body = PlainText(p.Contents)
+	}
+	return record
+}
+
+// WriteSearchIndex writes the search records of the proposals to w as a
+// tab indented json array, in the order of p.
+func (p Proposals) WriteSearchIndex(w io.Writer, plainText bool) error {
+	records := make([]SearchRecord, len(p))
+	for i, proposal := range p {
+		records[i] = proposal.SearchRecord(plainText)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(records)
+}
+
+// PlainText strips the markdown syntax that only adds noise to a search,
+// such as heading and list markers, code fences, emphasis, HTML tags and
+// link targets, from text. Blank lines are dropped.
+func PlainText(text string) string {
+	var lines []string
+	var fences fenceState
+	for _, line := range strings.Split(text, "\n") {
+		if fence, _ := fences.next(line); fence {
+			continue
+		}
+		if match := reHeading.FindStringSubmatch(line); match != nil {
+			line = match[2]
+		}
+		line = reListMarker.ReplaceAllString(line, "")
+		line = StripLinks(line)
+		line = reHTMLTag.ReplaceAllString(line, "")
+		line = reEmphasis.ReplaceAllString(line, "")
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestPlainText(t *testing.T) {
+	testcases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "headings and lists",
+			text:     "## Summary\n\n- first **item**\n1. second `item`\n> quoted\n",
+			expected: "Summary\nfirst item\nsecond item\nquoted",
+		},
+		{
+			name:     "links and tags",
+			text:     "<!-- toc -->\nSee [the design](design.md) and <b>more</b>.\n",
+			expected: "See the design and more.",
+		},
+		{
+			name:     "code fences",
+			text:     "```yaml\nkey:   value\n```\n",
+			expected: "key: value",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if text := keps.PlainText(tc.text); text != tc.expected {
+				t.Fatalf("expected %q but got %q", tc.expected, text)
+			}
+		})
+	}
+}
+
+func TestWriteSearchIndex(t *testing.T) {
+	proposals := keps.Proposals{
+		{
+			Title:     "Dry run",
+			OwningSIG: "sig-api-machinery",
+			Status:    "implemented",
+			Filename:  "sig-api-machinery/0015-dry-run.md",
+			Contents:  "## Summary\n\nSee [the <b>design</b>](design.md).\n",
+		},
+		{
+			Title:     "Unnumbered",
+			OwningSIG: "sig-node",
+			Status:    "provisional",
+			Filename:  "sig-node/20190101-unnumbered.md",
+		},
+	}
+	testcases := []struct {
+		name      string
+		plainText bool
+		expected  string
+	}{
+		{
+			name: "markdown body",
+			expected: `[
+	{
+		"number": 15,
+		"title": "Dry run",
+		"sig": "sig-api-machinery",
+		"status": "implemented",
+		"summary": "See the <b>design</b>.",
+		"url": "sig-api-machinery/0015-dry-run.md",
+		"body": "## Summary\n\nSee [the <b>design</b>](design.md).\n"
+	},
+	{
+		"number": null,
+		"title": "Unnumbered",
+		"sig": "sig-node",
+		"status": "provisional",
+		"summary": "",
+		"url": "sig-node/20190101-unnumbered.md",
+		"body": ""
+	}
+]
+`,
+		},
+		{
+			name:      "plain text body",
+			plainText: true,
+			expected: `[
+	{
+		"number": 15,
+		"title": "Dry run",
+		"sig": "sig-api-machinery",
+		"status": "implemented",
+		"summary": "See the <b>design</b>.",
+		"url": "sig-api-machinery/0015-dry-run.md",
+		"body": "Summary\nSee the design."
+	},
+	{
+		"number": null,
+		"title": "Unnumbered",
+		"sig": "sig-node",
+		"status": "provisional",
+		"summary": "",
+		"url": "sig-node/20190101-unnumbered.md",
+		"body": ""
+	}
+]
+`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := proposals.WriteSearchIndex(&out, tc.plainText); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tc.expected {
+				t.Fatalf("expected\n%s\nbut got\n%s", tc.expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/enhancements/pkg/kepval/keps/validations"
+)
+
+// ToTOC writes the proposals to w as a markdown table of contents. It
+// starts with a list of the owning SIGs and, below each, its statuses, that
+// link to a section for each SIG and status listing its proposals by title.
+// SIGs are sorted by name and statuses follow the KEP lifecycle, with
+// statuses that are not valid last. Each title links to the Filename of the
+// proposal, if it has one.
+func (p Proposals) ToTOC(w io.Writer) error {
+	groups := p.GroupBySIG()
+	sigs := make([]string, 0, len(groups))
+	for sig := range groups {
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+
+	out := bufio.NewWriter(w)
+	fmt.Fprint(out, "# Kubernetes Enhancement Proposals\n\n")
+	for _, sig := range sigs {
+		label := tocLabel(sig, "no owning SIG")
+		fmt.Fprintf(out, "- [%s](#%s)\n", label, Slug(label))
+		for _, status := range tocStatuses(groups[sig]) {
+			fmt.Fprintf(out, "  - [%s](#%s)\n", tocLabel(status, "no status"), Slug(tocHeading(sig, status)))
+		}
+	}
+	for _, sig := range sigs {
+		fmt.Fprintf(out, "\n## %s\n", tocLabel(sig, "no owning SIG"))
+		for _, status := range tocStatuses(groups[sig]) {
+			fmt.Fprintf(out, "\n### %s\n\n", tocHeading(sig, status))
+			selected := groups[sig].Filter(func(proposal *Proposal) bool { return proposal.Status == status })
+			sort.SliceStable(selected, func(i, j int) bool { return selected[i].Title < selected[j].Title })
+			for _, proposal := range selected {
+				title := escapeLinkText(proposal.Title)
+				if proposal.Filename == "" {
+					fmt.Fprintf(out, "- %s\n", title)
+				} else {
+					fmt.Fprintf(out, "- [%s](%s)\n", title, proposal.Filename)
+				}
+			}
+		}
+	}
+	return out.Flush()
+}
+
+// tocStatuses returns the statuses of proposals in lifecycle order, followed
+// by the statuses that are not valid sorted by name.
+func tocStatuses(proposals Proposals) []string {
+	counts := proposals.CountByStatus()
+	var statuses []string
+	for _, status := range validations.Statuses() {
+		if counts[status] > 0 {
+			statuses = append(statuses, status)
+			delete(counts, status)
+		}
+	}
+	var others []string
+	for status := range counts {
+		others = append(others, status)
+	}
+	sort.Strings(others)
+	return append(statuses, others...)
+}
+
+// tocHeading returns the heading of the section of the table of contents
+// for the proposals of sig with status. Naming the SIG keeps its anchor
+// unique.
+func tocHeading(sig, status string) string {
+	return tocLabel(sig, "no owning SIG") + " " + tocLabel(status, "no status")
+}
+
+// tocLabel returns value, or fallback if value is empty.
+func tocLabel(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// escapeLinkText makes text safe to use as the text of a markdown link.
+func escapeLinkText(text string) string {
+	text = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(text)
+	return strings.Join(strings.Fields(text), " ")
+}
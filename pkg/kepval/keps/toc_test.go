@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestToTOC(t *testing.T) {
+	proposals := keps.Proposals{
+		{Title: "Server Side Apply", OwningSIG: "sig-api-machinery", Status: "implementable", Filename: "sig-api-machinery/0006-apply.md"},
+		{Title: "Dry run", OwningSIG: "sig-api-machinery", Status: "implemented", Filename: "sig-api-machinery/0015-dry-run.md"},
+		{Title: "Admission webhooks", OwningSIG: "sig-api-machinery", Status: "implementable", Filename: "sig-api-machinery/00xx-admission-webhooks.md"},
+		{Title: "[WIP] Unlinked", OwningSIG: "sig-apps", Status: "provisional"},
+		{Title: "Odd status", OwningSIG: "sig-apps", Status: "drafting", Filename: "sig-apps/odd.md"},
+		{Title: "No status", OwningSIG: "sig-apps", Filename: "sig-apps/none.md"},
+	}
+	var out bytes.Buffer
+	if err := proposals.ToTOC(&out); err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile(filepath.Join("testdata", "proposals-toc.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != string(golden) {
+		t.Fatalf("output does not match testdata/proposals-toc.md, got:\n%s", out.String())
+	}
+}
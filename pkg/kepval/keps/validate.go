@@ -0,0 +1,702 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"k8s.io/enhancements/pkg/kepval/keps/validations"
+)
+
+// MinWordCount is the number of words below which a KEP body is reported as
+// a likely stub.
+var MinWordCount = 150
+
+// RationaleHeadings are the section headings that explain why a KEP was
+// deferred, rejected or withdrawn. One of them must be present in KEPs with
+// those statuses.
+var RationaleHeadings = []string{"Rejection Reason", "Withdrawal Reason", "Deferral Reason", "Withdrawal", "Rationale"}
+
+// PRRHeadings are the section headings of the Production Readiness Review
+// questionnaire. One of them must be present in KEPs that target beta or
+// stable.
+var PRRHeadings = []string{"Production Readiness Review Questionnaire"}
+
+// DisableKeywords are words of which the body of a KEP that supports
+// disabling its feature should mention at least one, to show that turning
+// the feature off is covered.
+var DisableKeywords = []string{"disable", "disabling", "rollback", "roll back"}
+
+// TodoMarkers are the words that mark unfinished parts of a KEP body. KEPs
+// that are implemented or stable should not contain them.
+var TodoMarkers = []string{"TODO", "FIXME", "XXX"}
+
+// Strict enables checking style rules that many existing KEPs do not follow
+// yet, such as participating-sigs not listing the owning SIG.
+var Strict = false
+
+// NumberWidth is the number of digits that the KEP number prefixes of paths,
+// such as 0015-dry-run.md, are zero padded to.
+var NumberWidth = 4
+
+// MaxKEPNumber is the highest kep-number that the metadata of a KEP may
+// record.
+var MaxKEPNumber = 9999
+
+// DateSkew is how far in the future a last-updated date may be before it is
+// reported, to allow for time zones and clock skew.
+var DateSkew = 24 * time.Hour
+
+// Now returns the current time that dates are checked against. It can be
+// replaced for reproducible runs.
+var Now = time.Now
+
+// CheckBodyPeople enables checking that the people mentioned in the
+// Reviewers and Approvers sections of the body are listed in the
+// corresponding metadata.
+var CheckBodyPeople = false
+
+// CheckCodeLanguages enables checking that the fenced code blocks of the
+// body only name languages in CodeLanguages.
+var CheckCodeLanguages = false
+
+// CodeLanguages are the languages, compared ignoring case, that fenced code
+// blocks may name, e.g. ```yaml. Blocks that do not name a language are
+// always accepted.
+var CodeLanguages = []string{
+	"bash", "c", "console", "cpp", "diff", "dockerfile", "go", "golang", "html",
+	"ini", "java", "javascript", "json", "make", "markdown", "md", "powershell",
+	"proto", "protobuf", "python", "sh", "shell", "sql", "text", "toml", "xml",
+	"yaml", "yml",
+}
+
+// CheckCodeExamples enables checking that the fenced yaml and json code
+// blocks of the body parse. It is off by default since some examples are
+// deliberately partial.
+var CheckCodeExamples = false
+
+// StrictMilestone enables checking that implementable and implemented KEPs
+// record the release they target. Many existing KEPs predate the milestone
+// keys, so it is off by default.
+var StrictMilestone = false
+
+// StrictMilestoneSeverity is how StrictMilestone reports a KEP without a
+// milestone.
+var StrictMilestoneSeverity = SeverityWarning
+
+// RequiredOwners, if set, are the owners that the authors, reviewers,
+// approvers and editor of every KEP must be among.
+var RequiredOwners *Owners
+
+// ImplementableReviewersSeverity is how an implementable KEP without
+// reviewers is reported.
+var ImplementableReviewersSeverity = SeverityWarning
+
+// AuthorsSeverity is how a KEP without authors is reported. Some existing
+// KEPs only name their author in the title, so it is a warning by default.
+var AuthorsSeverity = SeverityWarning
+
+// Severity is how a validation problem is reported. It can be used as a
+// flag.Value.
+type Severity string
+
+const (
+	// SeverityWarning problems are returned as a *Warning.
+	SeverityWarning Severity = "warning"
+	// SeverityError problems fail validation.
+	SeverityError Severity = "error"
+)
+
+func (s *Severity) String() string {
+	return string(*s)
+}
+
+func (s *Severity) Set(value string) error {
+	switch Severity(value) {
+	case SeverityWarning, SeverityError:
+		*s = Severity(value)
+		return nil
+	}
+	return errors.Errorf("unknown severity %q, must be one of: %s, %s", value, SeverityWarning, SeverityError)
+}
+
+// wrap returns err reported with severity s.
+func (s Severity) wrap(err error) error {
+	if s == SeverityWarning {
+		return &Warning{err}
+	}
+	return err
+}
+
+// Warning marks a validation problem that should be reported but should not
+// cause validation to fail.
+type Warning struct {
+	Err error
+}
+
+func (w *Warning) Error() string {
+	return w.Err.Error()
+}
+
+// IsWarning reports whether err is a Warning.
+func IsWarning(err error) bool {
+	_, ok := err.(*Warning)
+	return ok
+}
+
+// SplitWarnings separates the result of Validate into the problems that fail
+// validation and the warnings, keeping the order of each.
+func SplitWarnings(errs []error) (failures, warnings []error) {
+	for _, err := range errs {
+		if IsWarning(err) {
+			warnings = append(warnings, err)
+		} else {
+			failures = append(failures, err)
+		}
+	}
+	return failures, warnings
+}
+
+func init() {
+	RegisterValidator("unique-lists", validateUniqueLists)
+	RegisterValidator("quotes", validateQuotes)
+	RegisterValidator("participating-sigs", validateParticipatingSIGs)
+	RegisterValidator("authors", validateAuthors)
+	RegisterValidator("implementable-reviewers", validateImplementableReviewers)
+	RegisterValidator("tracking-issue", validateTrackingIssue)
+	RegisterValidator("kep-number", validateKEPNumber)
+	RegisterValidator("dates", validateDates)
+	RegisterValidator("milestones", validateMilestones)
+	RegisterValidator("strict-milestone", validateStrictMilestone)
+	RegisterValidator("anchors", validateAnchors)
+	RegisterValidator("heading-levels", validateHeadingLevels)
+	RegisterValidator("duplicate-anchors", validateDuplicateAnchors)
+	RegisterValidator("images", validateImages)
+	RegisterValidator("rationale", validateRationale)
+	RegisterValidator("graduation-criteria", validateGraduationCriteria)
+	RegisterValidator("graduation-stages", validateGraduationStages)
+	RegisterValidator("prr", validatePRR)
+	RegisterValidator("todos", validateTodos)
+	RegisterValidator("body-people", validateBodyPeople)
+	RegisterValidator("owners", validateOwners)
+	RegisterValidator("disable-supported", validateDisableSupported)
+	RegisterValidator("code-languages", validateCodeLanguages)
+	RegisterValidator("code-examples", validateCodeExamples)
+	RegisterValidator("stub", validateStub)
+}
+
+// Validate runs the semantic checks that need the decoded proposal rather
+// than the raw YAML structure. It runs every enabled validator, see
+// RegisterValidator and EnableValidators, and returns every problem found
+// instead of stopping at the first one. Problems that should not fail
+// validation are returned as a *Warning.
+func (p *Proposal) Validate() []error {
+	var errs []error
+	for _, v := range validators {
+		if enabled == nil || enabled[v.name] {
+			errs = append(errs, v.fn(p)...)
+		}
+	}
+	return errs
+}
+
+func validateUniqueLists(p *Proposal) []error {
+	var errs []error
+	lists := []struct {
+		key    string
+		values []string
+	}{
+		{"participating-sigs", p.ParticipatingSIGs},
+		{"see-also", p.SeeAlso},
+		{"replaces", p.Replaces},
+		{"superseded-by", p.SupersededBy},
+	}
+	for _, list := range lists {
+		if err := validations.ValidateUnique(list.key, list.values); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// metadataString is a string metadata value of a proposal, or an entry of
+// one of its string lists.
+type metadataString struct {
+	key   string
+	value *string
+}
+
+// metadataStrings returns the string metadata values of p, with one entry
+// for each element of its string lists.
+func (p *Proposal) metadataStrings() []metadataString {
+	values := []metadataString{
+		{"title", &p.Title},
+		{"owning-sig", &p.OwningSIG},
+		{"editor", &p.Editor},
+		{"status", &p.Status},
+		{"tracking-issue", &p.TrackingIssue},
+		{"stage", &p.Stage},
+		{"latest-milestone", &p.LatestMilestone},
+	}
+	lists := []struct {
+		key    string
+		values []string
+	}{
+		{"authors", p.Authors},
+		{"participating-sigs", p.ParticipatingSIGs},
+		{"reviewers", p.Reviewers},
+		{"approvers", p.Approvers},
+		{"see-also", p.SeeAlso},
+		{"replaces", p.Replaces},
+		{"superseded-by", p.SupersededBy},
+	}
+	for _, list := range lists {
+		for i := range list.values {
+			values = append(values, metadataString{list.key, &list.values[i]})
+		}
+	}
+	return values
+}
+
+func validateQuotes(p *Proposal) []error {
+	var errs []error
+	for _, s := range p.metadataStrings() {
+		if err := validations.ValidateStraightQuotes(s.key, *s.value); err != nil {
+			errs = append(errs, &Warning{err})
+		}
+	}
+	return errs
+}
+
+func validateParticipatingSIGs(p *Proposal) []error {
+	if !Strict {
+		return nil
+	}
+	if err := validations.ValidateNotParticipating(p.OwningSIG, p.ParticipatingSIGs); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+func validateAuthors(p *Proposal) []error {
+	switch p.Status {
+	case "rejected", "withdrawn", "replaced":
+		// historical KEPs may not record their authors
+		return nil
+	}
+	if err := validations.ValidateRequiredList("authors", p.Authors); err != nil {
+		return []error{AuthorsSeverity.wrap(err)}
+	}
+	return nil
+}
+
+func validateImplementableReviewers(p *Proposal) []error {
+	if p.Status != "implementable" {
+		return nil
+	}
+	if err := validations.ValidateRequiredList("reviewers", p.Reviewers); err != nil {
+		return []error{ImplementableReviewersSeverity.wrap(err)}
+	}
+	return nil
+}
+
+func validateTrackingIssue(p *Proposal) []error {
+	if p.TrackingIssue == "" {
+		return nil
+	}
+	if err := validations.ValidateTrackingIssue(p.TrackingIssue); err != nil {
+		return []error{&Warning{err}}
+	}
+	return nil
+}
+
+func validateKEPNumber(p *Proposal) []error {
+	if p.KEPNumber == nil {
+		return nil
+	}
+	if err := validations.ValidateKEPNumber(*p.KEPNumber, MaxKEPNumber); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+func validateDates(p *Proposal) []error {
+	var errs []error
+	updated, updatedOK := p.Updated()
+	if updatedOK {
+		if err := validations.ValidateNotFuture("last-updated", updated, Now().Add(DateSkew)); err != nil {
+			errs = append(errs, &Warning{err})
+		}
+	}
+	created, createdOK := p.Created()
+	if !createdOK || !updatedOK {
+		return errs
+	}
+	// several existing KEPs have inverted dates, so report them without
+	// failing
+	if err := validations.ValidateDateOrder(created, updated); err != nil {
+		errs = append(errs, &Warning{err})
+	}
+	return errs
+}
+
+func validateMilestones(p *Proposal) []error {
+	if p.LatestMilestone == "" {
+		return nil
+	}
+	if _, err := ParseKubeVersion(p.LatestMilestone); err != nil {
+		return nil
+	}
+	var highest string
+	var highestVersion KubeVersion
+	for _, milestone := range p.Milestone {
+		version, err := ParseKubeVersion(milestone)
+		if err != nil {
+			continue
+		}
+		if highest == "" || highestVersion.Less(version) {
+			highest, highestVersion = milestone, version
+		}
+	}
+	if highest == "" {
+		return nil
+	}
+	if err := validations.ValidateLatestMilestone(p.LatestMilestone, highest); err != nil {
+		return []error{&Warning{err}}
+	}
+	return nil
+}
+
+func validateStrictMilestone(p *Proposal) []error {
+	// provisional KEPs do not target a release yet, and the other statuses
+	// no longer do
+	if !StrictMilestone || (p.Status != "implementable" && p.Status != "implemented") {
+		return nil
+	}
+	if err := validations.ValidateMilestoneSet(p.Status, p.LatestMilestone, p.Milestone); err != nil {
+		return []error{StrictMilestoneSeverity.wrap(err)}
+	}
+	return nil
+}
+
+func validateAnchors(p *Proposal) []error {
+	var errs []error
+	// generated tables of contents in existing KEPs often have stale links,
+	// so report them without failing
+	anchors := p.Anchors()
+	for _, link := range p.anchorLinks() {
+		if err := validations.ValidateAnchor(link.anchor, link.line, anchors); err != nil {
+			errs = append(errs, &Warning{err})
+		}
+	}
+	return errs
+}
+
+func validateHeadingLevels(p *Proposal) []error {
+	var errs []error
+	headings := p.Headings()
+	for i := 1; i < len(headings); i++ {
+		if err := validations.ValidateHeadingLevel(headings[i-1].Level, headings[i].Level, headings[i].Line); err != nil {
+			errs = append(errs, &Warning{err})
+		}
+	}
+	return errs
+}
+
+func validateDuplicateAnchors(p *Proposal) []error {
+	var errs []error
+	// links to a repeated heading silently go to the first one, so point
+	// out the anchor GitHub gives the repeat
+	first := map[string]int{}
+	for _, heading := range p.Headings() {
+		anchor := Slug(heading.Text)
+		if err := validations.ValidateUniqueAnchor(heading.Text, heading.Line, anchor, first[anchor], heading.Anchor); err != nil {
+			errs = append(errs, &Warning{err})
+			continue
+		}
+		first[anchor] = heading.Line
+	}
+	return errs
+}
+
+func validateImages(p *Proposal) []error {
+	if p.fsys == nil {
+		return nil
+	}
+	var errs []error
+	for _, image := range p.Images() {
+		if !image.IsRelative() {
+			continue
+		}
+		name := image.Src
+		if i := strings.IndexAny(name, "?#"); i >= 0 {
+			name = name[:i]
+		}
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+		_, err := fs.Stat(p.fsys, path.Join(p.dir, name))
+		if err := validations.ValidateImage(image.Src, image.Line, err == nil); err != nil {
+			errs = append(errs, &Warning{err})
+		}
+	}
+	return errs
+}
+
+func validateRationale(p *Proposal) []error {
+	switch p.Status {
+	case "deferred", "rejected", "withdrawn":
+		reason := fmt.Sprintf("status is %s", p.Status)
+		if err := validations.ValidateSection(p.HasSection(RationaleHeadings...), reason, RationaleHeadings); err != nil {
+			return []error{&Warning{err}}
+		}
+	}
+	return nil
+}
+
+func validateGraduationCriteria(p *Proposal) []error {
+	if p.Status != "implemented" {
+		return nil
+	}
+	const section = "Graduation Criteria"
+	if err := validations.ValidateChecked("status is implemented", section, p.UncheckedItems(section)); err != nil {
+		return []error{&Warning{err}}
+	}
+	return nil
+}
+
+// graduationStages are the stages of the milestone map, in order, with the
+// pattern that the heading of their Graduation Criteria subsection matches.
+var graduationStages = []struct {
+	stage   string
+	heading *regexp.Regexp
+}{
+	{"alpha", regexp.MustCompile(`(?i)\balpha\b`)},
+	{"beta", regexp.MustCompile(`(?i)\bbeta\b`)},
+	{"stable", regexp.MustCompile(`(?i)\b(?:ga|stable|general availability)\b`)},
+}
+
+func validateGraduationStages(p *Proposal) []error {
+	const section = "Graduation Criteria"
+	if !p.HasSection(section) {
+		return nil
+	}
+	subsections := p.Subsections(section)
+	var errs []error
+	for _, graduation := range graduationStages {
+		if _, ok := p.MilestoneForStage(graduation.stage); !ok {
+			continue
+		}
+		found := false
+		for _, heading := range subsections {
+			if graduation.heading.MatchString(heading.Text) {
+				found = true
+				break
+			}
+		}
+		if err := validations.ValidateStageSection(found, section, graduation.stage); err != nil {
+			errs = append(errs, &Warning{err})
+		}
+	}
+	return errs
+}
+
+func validatePRR(p *Proposal) []error {
+	var reason string
+	switch {
+	case p.Stage == "beta" || p.Stage == "stable":
+		reason = fmt.Sprintf("stage is %s", p.Stage)
+	case hasMilestone(p, "stable"):
+		reason = "a stable milestone is set"
+	case hasMilestone(p, "beta"):
+		reason = "a beta milestone is set"
+	default:
+		return nil
+	}
+	if err := validations.ValidateSection(p.HasSection(PRRHeadings...), reason, PRRHeadings); err != nil {
+		return []error{&Warning{err}}
+	}
+	return nil
+}
+
+func hasMilestone(p *Proposal, stage string) bool {
+	_, ok := p.MilestoneForStage(stage)
+	return ok
+}
+
+func validateTodos(p *Proposal) []error {
+	var reason string
+	switch {
+	case p.Status == "implemented":
+		reason = "status is implemented"
+	case p.Stage == "stable":
+		reason = "stage is stable"
+	default:
+		return nil
+	}
+	if err := validations.ValidateResolved(reason, TodoMarkers, p.MarkerLines(TodoMarkers...)); err != nil {
+		return []error{&Warning{err}}
+	}
+	return nil
+}
+
+func validateBodyPeople(p *Proposal) []error {
+	if !CheckBodyPeople {
+		return nil
+	}
+	var errs []error
+	sections := []struct {
+		title  string
+		key    string
+		values []string
+	}{
+		{"Reviewers", "reviewers", p.Reviewers},
+		{"Approvers", "approvers", p.Approvers},
+	}
+	for _, section := range sections {
+		for _, mention := range p.Mentions(section.title) {
+			if err := validations.ValidateHandleListed(section.key, mention.Handle, mention.Line, section.values); err != nil {
+				errs = append(errs, &Warning{err})
+			}
+		}
+	}
+	return errs
+}
+
+func validateOwners(p *Proposal) []error {
+	if RequiredOwners == nil {
+		return nil
+	}
+	var errs []error
+	owners := RequiredOwners.Handles()
+	// most KEPs leave the editor as TBD
+	var editors []string
+	if strings.HasPrefix(p.Editor, "@") {
+		editors = []string{p.Editor}
+	}
+	lists := []struct {
+		key    string
+		values []string
+	}{
+		{"authors", p.Authors},
+		{"reviewers", p.Reviewers},
+		{"approvers", p.Approvers},
+		{"editor", editors},
+	}
+	for _, list := range lists {
+		for _, handle := range list.values {
+			if err := validations.ValidateOwner(list.key, handle, owners, RequiredOwners.Source); err != nil {
+				errs = append(errs, &Warning{err})
+			}
+		}
+	}
+	return errs
+}
+
+func validateDisableSupported(p *Proposal) []error {
+	if !p.DisableSupported {
+		return nil
+	}
+	body := strings.ToLower(p.Contents)
+	found := false
+	for _, keyword := range DisableKeywords {
+		if strings.Contains(body, strings.ToLower(keyword)) {
+			found = true
+			break
+		}
+	}
+	if err := validations.ValidateMentioned(found, "disable-supported is true", DisableKeywords); err != nil {
+		return []error{&Warning{err}}
+	}
+	return nil
+}
+
+func validateCodeLanguages(p *Proposal) []error {
+	if !CheckCodeLanguages {
+		return nil
+	}
+	var errs []error
+	for _, fence := range p.CodeFences() {
+		if fence.Language == "" {
+			continue
+		}
+		if err := validations.ValidateLanguage(fence.Language, fence.Line, CodeLanguages); err != nil {
+			errs = append(errs, &Warning{err})
+		}
+	}
+	return errs
+}
+
+func validateCodeExamples(p *Proposal) []error {
+	if !CheckCodeExamples {
+		return nil
+	}
+	var errs []error
+	for _, fence := range p.CodeFences() {
+		var err error
+		switch strings.ToLower(fence.Language) {
+		case "yaml", "yml":
+			err = parseYAMLDocuments(fence.Code)
+		case "json":
+			var value interface{}
+			err = json.Unmarshal([]byte(fence.Code), &value)
+		default:
+			continue
+		}
+		if err := validations.ValidateCodeExample(fence.Language, fence.Line, err); err != nil {
+			errs = append(errs, &Warning{err})
+		}
+	}
+	return errs
+}
+
+// parseYAMLDocuments parses every document of code, which may be a stream
+// of documents separated by ---.
+func parseYAMLDocuments(code string) error {
+	decoder := yaml.NewDecoder(strings.NewReader(code))
+	for {
+		var document interface{}
+		if err := decoder.Decode(&document); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func validateStub(p *Proposal) []error {
+	if err := validations.ValidateWordCount(p.WordCount(), MinWordCount); err != nil {
+		return []error{&Warning{err}}
+	}
+	return nil
+}
+
+// WordCount returns the number of words in the KEP body, excluding the
+// frontmatter.
+func (p *Proposal) WordCount() int {
+	return len(strings.Fields(p.Contents))
+}
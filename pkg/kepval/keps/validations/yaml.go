@@ -21,11 +21,16 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
+const dateFormat = "2006-01-02"
+
 type KeyMustBeSpecified struct {
 	key interface{}
 }
@@ -34,6 +39,10 @@ func (k *KeyMustBeSpecified) Error() string {
 	return fmt.Sprintf("missing key %[1]v", k.key)
 }
 
+func (k *KeyMustBeSpecified) Key() string {
+	return fmt.Sprint(k.key)
+}
+
 type KeyMustBeString struct {
 	key interface{}
 }
@@ -51,6 +60,10 @@ func (v *ValueMustBeString) Error() string {
 	return fmt.Sprintf("%q must be a string but it is a %T: %v", v.key, v.value, v.value)
 }
 
+func (v *ValueMustBeString) Key() string {
+	return v.key
+}
+
 type ValueMustBeOneOf struct {
 	key    string
 	value  string
@@ -61,6 +74,10 @@ func (v *ValueMustBeOneOf) Error() string {
 	return fmt.Sprintf("%q must be one of (%s) but it is a %T: %v", v.key, strings.Join(v.values, ","), v.value, v.value)
 }
 
+func (v *ValueMustBeOneOf) Key() string {
+	return v.key
+}
+
 type ValueMustBeListOfStrings struct {
 	key   string
 	value interface{}
@@ -70,6 +87,23 @@ func (v *ValueMustBeListOfStrings) Error() string {
 	return fmt.Sprintf("%q must be a list of strings: %v", v.key, v.value)
 }
 
+func (v *ValueMustBeListOfStrings) Key() string {
+	return v.key
+}
+
+type ValueMustBeInteger struct {
+	key   string
+	value interface{}
+}
+
+func (v *ValueMustBeInteger) Error() string {
+	return fmt.Sprintf("%q must be an integer but it is a %T: %v", v.key, v.value, v.value)
+}
+
+func (v *ValueMustBeInteger) Key() string {
+	return v.key
+}
+
 type MustHaveOneValue struct {
 	key string
 }
@@ -78,6 +112,10 @@ func (m *MustHaveOneValue) Error() string {
 	return fmt.Sprintf("%q must have a value", m.key)
 }
 
+func (m *MustHaveOneValue) Key() string {
+	return m.key
+}
+
 type MustHaveAtLeastOneValue struct {
 	key string
 }
@@ -86,6 +124,10 @@ func (m *MustHaveAtLeastOneValue) Error() string {
 	return fmt.Sprintf("%q must have at least one value", m.key)
 }
 
+func (m *MustHaveAtLeastOneValue) Key() string {
+	return m.key
+}
+
 var listGroups []string
 
 func init() {
@@ -115,6 +157,12 @@ var mandatoryKeys = []string{"title", "owning-sig"}
 var statuses = []string{"provisional", "implementable", "implemented", "deferred", "rejected", "withdrawn", "replaced"}
 var reStatus = regexp.MustCompile(strings.Join(statuses, "|"))
 
+// Statuses returns the values status may have, in the order a KEP goes
+// through them.
+func Statuses() []string {
+	return append([]string(nil), statuses...)
+}
+
 func ValidateStructure(parsed map[interface{}]interface{}) error {
 	for _, key := range mandatoryKeys {
 		if _, found := parsed[key]; !found {
@@ -169,6 +217,13 @@ func ValidateStructure(parsed map[interface{}]interface{}) error {
 			if !ok {
 				return &ValueMustBeString{k, v}
 			}
+		case "kep-number":
+			if empty {
+				continue
+			}
+			if _, ok := value.(int); !ok {
+				return &ValueMustBeInteger{k, value}
+			}
 		// These are optional lists, so skip if there is no value
 		case "participating-sigs", "replaces", "superseded-by", "see-also":
 			if empty {
@@ -206,3 +261,694 @@ func ValidateStructure(parsed map[interface{}]interface{}) error {
 	}
 	return nil
 }
+
+type ValueMustBeUnique struct {
+	key   string
+	value string
+}
+
+func (v *ValueMustBeUnique) Error() string {
+	return fmt.Sprintf("%q must not contain duplicates but %q is listed more than once", v.key, v.value)
+}
+
+func (v *ValueMustBeUnique) Key() string {
+	return v.key
+}
+
+// ValidateUnique checks that values, the contents of the list field key,
+// contains no entry more than once. Entries are compared case-insensitively.
+func ValidateUnique(key string, values []string) error {
+	seen := map[string]bool{}
+	for _, value := range values {
+		v := strings.ToLower(value)
+		if seen[v] {
+			return &ValueMustBeUnique{key, value}
+		}
+		seen[v] = true
+	}
+	return nil
+}
+
+type OwningSIGMustNotParticipate struct {
+	sig string
+}
+
+func (o *OwningSIGMustNotParticipate) Error() string {
+	return fmt.Sprintf("\"participating-sigs\" lists the owning SIG %q, remove it or run kepify -fix", o.sig)
+}
+
+// ValidateNotParticipating checks that participating, the participating
+// SIGs of a KEP, does not list owningSIG.
+func ValidateNotParticipating(owningSIG string, participating []string) error {
+	for _, sig := range participating {
+		if sig == owningSIG {
+			return &OwningSIGMustNotParticipate{owningSIG}
+		}
+	}
+	return nil
+}
+
+type BodyTooShort struct {
+	words int
+	min   int
+}
+
+func (b *BodyTooShort) Error() string {
+	return fmt.Sprintf("body has %d words, fewer than %d, and is likely a stub", b.words, b.min)
+}
+
+// ValidateWordCount checks that a KEP body of the given number of words is
+// not shorter than min.
+func ValidateWordCount(words, min int) error {
+	if words < min {
+		return &BodyTooShort{words, min}
+	}
+	return nil
+}
+
+type DateMustNotBeAfter struct {
+	key        string
+	value      string
+	otherKey   string
+	otherValue string
+}
+
+func (d *DateMustNotBeAfter) Error() string {
+	return fmt.Sprintf("%q must not be after %q but %s is after %s", d.key, d.otherKey, d.value, d.otherValue)
+}
+
+func (d *DateMustNotBeAfter) Key() string {
+	return d.key
+}
+
+// ValidateDateOrder checks that the creation date of a KEP is not after the
+// date it was last updated.
+func ValidateDateOrder(created, updated time.Time) error {
+	if created.After(updated) {
+		return &DateMustNotBeAfter{"creation-date", created.Format(dateFormat), "last-updated", updated.Format(dateFormat)}
+	}
+	return nil
+}
+
+type DateMustNotBeInFuture struct {
+	key    string
+	value  string
+	latest string
+}
+
+func (d *DateMustNotBeInFuture) Error() string {
+	return fmt.Sprintf("%q must not be in the future but %s is after %s", d.key, d.value, d.latest)
+}
+
+func (d *DateMustNotBeInFuture) Key() string {
+	return d.key
+}
+
+// ValidateNotFuture checks that date, the value of key, is not after latest,
+// the current time plus any tolerated clock skew.
+func ValidateNotFuture(key string, date, latest time.Time) error {
+	if date.After(latest) {
+		return &DateMustNotBeInFuture{key, date.Format(dateFormat), latest.Format(dateFormat)}
+	}
+	return nil
+}
+
+type AnchorMustExist struct {
+	anchor string
+	line   int
+}
+
+func (a *AnchorMustExist) Error() string {
+	return fmt.Sprintf("line %d links to #%s but no heading has that anchor", a.line, a.anchor)
+}
+
+func (a *AnchorMustExist) Line() int {
+	return a.line
+}
+
+// ValidateAnchor checks that an in-page link to anchor on the given line
+// targets one of the anchors generated for the KEP's headings.
+func ValidateAnchor(anchor string, line int, anchors map[string]bool) error {
+	if !anchors[strings.ToLower(anchor)] {
+		return &AnchorMustExist{anchor, line}
+	}
+	return nil
+}
+
+type SectionMustExist struct {
+	reason   string
+	sections []string
+}
+
+func (s *SectionMustExist) Error() string {
+	return fmt.Sprintf("%s, so the body must have one of these sections: %s", s.reason, strings.Join(s.sections, ", "))
+}
+
+// ValidateSection checks that a KEP that must have one of sections for the
+// given reason has one.
+func ValidateSection(found bool, reason string, sections []string) error {
+	if !found {
+		return &SectionMustExist{reason, sections}
+	}
+	return nil
+}
+
+type StageMustHaveSection struct {
+	section string
+	stage   string
+}
+
+func (s *StageMustHaveSection) Error() string {
+	return fmt.Sprintf("a %s milestone is set, so the %q section must have a subsection for %s", s.stage, s.section, s.stage)
+}
+
+// ValidateStageSection checks that section, which lists the criteria of each
+// stage, has a subsection for stage when found says whether it does.
+func ValidateStageSection(found bool, section, stage string) error {
+	if !found {
+		return &StageMustHaveSection{section, stage}
+	}
+	return nil
+}
+
+type ItemsMustBeChecked struct {
+	reason    string
+	section   string
+	unchecked []int
+}
+
+func (i *ItemsMustBeChecked) Error() string {
+	return fmt.Sprintf("%s, but the %q section has %d unchecked items, the first on line %d", i.reason, i.section, len(i.unchecked), i.unchecked[0])
+}
+
+// ValidateChecked checks that a KEP that must have every item of section
+// checked for the given reason has no unchecked items. unchecked are the
+// line numbers of the unchecked items.
+func ValidateChecked(reason, section string, unchecked []int) error {
+	if len(unchecked) > 0 {
+		return &ItemsMustBeChecked{reason, section, unchecked}
+	}
+	return nil
+}
+
+type MarkersMustBeResolved struct {
+	reason  string
+	markers []string
+	lines   []int
+}
+
+func (m *MarkersMustBeResolved) Error() string {
+	lines := make([]string, len(m.lines))
+	for i, line := range m.lines {
+		lines[i] = strconv.Itoa(line)
+	}
+	noun := "lines"
+	if len(lines) == 1 {
+		noun = "line"
+	}
+	return fmt.Sprintf("%s, but the body still has %s markers on %s %s", m.reason, strings.Join(m.markers, "/"), noun, strings.Join(lines, ", "))
+}
+
+// ValidateResolved checks that a KEP that must be finished for the given
+// reason has no markers left. lines are the line numbers of the lines with a
+// marker.
+func ValidateResolved(reason string, markers []string, lines []int) error {
+	if len(lines) > 0 {
+		return &MarkersMustBeResolved{reason, markers, lines}
+	}
+	return nil
+}
+
+type HandleMustBeListed struct {
+	key    string
+	handle string
+	line   int
+}
+
+func (h *HandleMustBeListed) Error() string {
+	return fmt.Sprintf("line %d mentions %s but %q does not list them", h.line, h.handle, h.key)
+}
+
+func (h *HandleMustBeListed) Key() string {
+	return h.key
+}
+
+func (h *HandleMustBeListed) Line() int {
+	return h.line
+}
+
+// ValidateHandleListed checks that handle, a GitHub handle mentioned on line
+// of the body, is one of values, the contents of the list field key. Handles
+// are compared case-insensitively and the leading @ is optional.
+func ValidateHandleListed(key, handle string, line int, values []string) error {
+	if !containsHandle(values, handle) {
+		return &HandleMustBeListed{key, handle, line}
+	}
+	return nil
+}
+
+type HandleMustBeOwner struct {
+	key    string
+	handle string
+	source string
+}
+
+func (h *HandleMustBeOwner) Error() string {
+	return fmt.Sprintf("%q lists %s, who is not an approver or reviewer in %s", h.key, h.handle, h.source)
+}
+
+func (h *HandleMustBeOwner) Key() string {
+	return h.key
+}
+
+// ValidateOwner checks that handle, a value of the list field key, is one of
+// owners, the approvers and reviewers read from source. Handles are
+// compared like in ValidateHandleListed.
+func ValidateOwner(key, handle string, owners []string, source string) error {
+	if !containsHandle(owners, handle) {
+		return &HandleMustBeOwner{key, handle, source}
+	}
+	return nil
+}
+
+// containsHandle reports whether handle is one of values, ignoring case and
+// the leading @.
+func containsHandle(values []string, handle string) bool {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "@"))
+	}
+	for _, value := range values {
+		if normalize(value) == normalize(handle) {
+			return true
+		}
+	}
+	return false
+}
+
+type BodyMustMention struct {
+	reason string
+	words  []string
+}
+
+func (b *BodyMustMention) Error() string {
+	return fmt.Sprintf("%s, so the body should mention one of: %s", b.reason, strings.Join(b.words, ", "))
+}
+
+// ValidateMentioned checks that a KEP that should mention one of words for
+// the given reason does.
+func ValidateMentioned(found bool, reason string, words []string) error {
+	if !found {
+		return &BodyMustMention{reason, words}
+	}
+	return nil
+}
+
+type LanguageMustBeKnown struct {
+	language string
+	line     int
+}
+
+func (l *LanguageMustBeKnown) Error() string {
+	return fmt.Sprintf("line %d opens a code block in unknown language %q", l.line, l.language)
+}
+
+func (l *LanguageMustBeKnown) Line() int {
+	return l.line
+}
+
+// ValidateLanguage checks that language, named by the code fence on line, is
+// one of known, ignoring case.
+func ValidateLanguage(language string, line int, known []string) error {
+	for _, k := range known {
+		if strings.EqualFold(k, language) {
+			return nil
+		}
+	}
+	return &LanguageMustBeKnown{language, line}
+}
+
+type CodeExampleMustParse struct {
+	language string
+	line     int
+	err      error
+}
+
+func (c *CodeExampleMustParse) Error() string {
+	return fmt.Sprintf("the %s code block opened on line %d does not parse: %v", c.language, c.line, c.err)
+}
+
+func (c *CodeExampleMustParse) Line() int {
+	return c.line
+}
+
+// ValidateCodeExample reports err, the error parsing the code block in
+// language opened by the fence on line, if it is not nil.
+func ValidateCodeExample(language string, line int, err error) error {
+	if err != nil {
+		return &CodeExampleMustParse{language, line, err}
+	}
+	return nil
+}
+
+type ImageMustExist struct {
+	src  string
+	line int
+}
+
+func (i *ImageMustExist) Error() string {
+	return fmt.Sprintf("line %d embeds image %q, which does not exist", i.line, i.src)
+}
+
+func (i *ImageMustExist) Line() int {
+	return i.line
+}
+
+// ValidateImage checks that the image src embedded on line exists.
+func ValidateImage(src string, line int, exists bool) error {
+	if !exists {
+		return &ImageMustExist{src, line}
+	}
+	return nil
+}
+
+type HeadingMustNotSkipLevel struct {
+	previous int
+	level    int
+	line     int
+}
+
+func (h *HeadingMustNotSkipLevel) Error() string {
+	return fmt.Sprintf("line %d has a level %d heading directly below a level %d heading", h.line, h.level, h.previous)
+}
+
+func (h *HeadingMustNotSkipLevel) Line() int {
+	return h.line
+}
+
+// ValidateHeadingLevel checks that a heading of the given level on line is
+// at most one level deeper than the heading before it.
+func ValidateHeadingLevel(previous, level, line int) error {
+	if level > previous+1 {
+		return &HeadingMustNotSkipLevel{previous, level, line}
+	}
+	return nil
+}
+
+type AnchorMustBeUnique struct {
+	heading string
+	line    int
+	anchor  string
+	first   int
+	suffix  string
+}
+
+func (a *AnchorMustBeUnique) Error() string {
+	return fmt.Sprintf("line %d has the heading %q, whose anchor #%s is already used by the heading on line %d; GitHub links to it as #%s", a.line, a.heading, a.anchor, a.first, a.suffix)
+}
+
+func (a *AnchorMustBeUnique) Line() int {
+	return a.line
+}
+
+// ValidateUniqueAnchor checks that the heading on line, whose anchor is
+// anchor before GitHub disambiguates it as suffixed, does not repeat the
+// anchor of an earlier heading, which is on the line first or 0 if there is
+// none.
+func ValidateUniqueAnchor(heading string, line int, anchor string, first int, suffixed string) error {
+	if first != 0 {
+		return &AnchorMustBeUnique{heading, line, anchor, first, suffixed}
+	}
+	return nil
+}
+
+type ValueMustBeIssue struct {
+	key   string
+	value string
+}
+
+func (v *ValueMustBeIssue) Error() string {
+	return fmt.Sprintf("%q must be an issue number or a GitHub issue URL but it is %q", v.key, v.value)
+}
+
+func (v *ValueMustBeIssue) Key() string {
+	return v.key
+}
+
+var reIssue = regexp.MustCompile(`^(#?\d+|https://github\.com/[^/]+/[^/]+/issues/\d+)$`)
+
+// ValidateTrackingIssue checks that the tracking-issue of a KEP is either an
+// issue number, optionally prefixed with #, or the URL of a GitHub issue.
+func ValidateTrackingIssue(value string) error {
+	if !reIssue.MatchString(value) {
+		return &ValueMustBeIssue{"tracking-issue", value}
+	}
+	return nil
+}
+
+// ValidateRequiredList checks that the list field key has at least one value.
+func ValidateRequiredList(key string, values []string) error {
+	if len(values) == 0 {
+		return &MustHaveAtLeastOneValue{key}
+	}
+	return nil
+}
+
+type LatestMilestoneMustMatch struct {
+	latest  string
+	highest string
+}
+
+func (l *LatestMilestoneMustMatch) Error() string {
+	return fmt.Sprintf("\"latest-milestone\" is %q but the highest version in \"milestone\" is %q", l.latest, l.highest)
+}
+
+// ValidateLatestMilestone checks that latest, the latest-milestone of a KEP,
+// is the same version as highest, the highest version in its milestone map.
+// The leading v of a version is optional.
+func ValidateLatestMilestone(latest, highest string) error {
+	if strings.TrimPrefix(latest, "v") != strings.TrimPrefix(highest, "v") {
+		return &LatestMilestoneMustMatch{latest, highest}
+	}
+	return nil
+}
+
+type MilestoneMustBeSet struct {
+	status string
+}
+
+func (m *MilestoneMustBeSet) Error() string {
+	return fmt.Sprintf("%s KEPs must record the release they target in \"latest-milestone\" or \"milestone\"", m.status)
+}
+
+// ValidateMilestoneSet checks that a KEP with the given status sets latest,
+// its latest-milestone, or at least one release in milestones.
+func ValidateMilestoneSet(status, latest string, milestones map[string]string) error {
+	if latest != "" {
+		return nil
+	}
+	for _, milestone := range milestones {
+		if milestone != "" {
+			return nil
+		}
+	}
+	return &MilestoneMustBeSet{status}
+}
+
+type MetadataMustAgree struct {
+	key  string
+	file string
+}
+
+func (m *MetadataMustAgree) Error() string {
+	return fmt.Sprintf("%q has different values in the frontmatter and in %s, using the one from %s", m.key, m.file, m.file)
+}
+
+func (m *MetadataMustAgree) Key() string {
+	return m.key
+}
+
+// ValidateMetadataAgrees checks that the metadata key has the same value in
+// the frontmatter of a KEP and in file, the separate metadata file of the
+// KEP.
+func ValidateMetadataAgrees(key string, frontmatter, value interface{}, file string) error {
+	if !reflect.DeepEqual(frontmatter, value) {
+		return &MetadataMustAgree{key, file}
+	}
+	return nil
+}
+
+type KeyMustBeCanonical struct {
+	key       string
+	canonical string
+}
+
+func (k *KeyMustBeCanonical) Error() string {
+	return fmt.Sprintf("key %q is read as %q, but other casings are deprecated, write it as %q", k.key, k.canonical, k.canonical)
+}
+
+func (k *KeyMustBeCanonical) Key() string {
+	return k.key
+}
+
+// ValidateKeyCase checks that the metadata key is spelled exactly like
+// canonical, the key it matches ignoring case.
+func ValidateKeyCase(key, canonical string) error {
+	if key != canonical {
+		return &KeyMustBeCanonical{key, canonical}
+	}
+	return nil
+}
+
+type HashMustBeUnique struct {
+	hash   string
+	first  string
+	second string
+}
+
+func (h *HashMustBeUnique) Error() string {
+	return fmt.Sprintf("%s and %s have the same owning-sig and title, so both would be written as %s", h.first, h.second, h.hash)
+}
+
+// ValidateUniqueHash checks that second, a KEP whose output key is hash, is
+// not already written under that key by first, which is empty when the key
+// is still free.
+func ValidateUniqueHash(hash, first, second string) error {
+	if first != "" {
+		return &HashMustBeUnique{hash, first, second}
+	}
+	return nil
+}
+
+type OwningSIGMustBeWellFormed struct {
+	file string
+	sig  string
+}
+
+func (o *OwningSIGMustBeWellFormed) Error() string {
+	if o.sig == "" {
+		return fmt.Sprintf("%s has no owning-sig, so its output key would collide with KEPs of the same title in other SIGs", o.file)
+	}
+	return fmt.Sprintf("%s has the owning-sig %q, which must be lowercase words joined by dashes, such as sig-node", o.file, o.sig)
+}
+
+// reOwningSIG matches an owning SIG such as sig-api-machinery. It cannot
+// contain the colon that separates the owning SIG from the title in the
+// output key.
+var reOwningSIG = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidateOwningSIGFormat checks that sig, the owning SIG of the KEP file,
+// is set and well formed, so it can be part of the output key of the KEP.
+func ValidateOwningSIGFormat(file, sig string) error {
+	if !reOwningSIG.MatchString(sig) {
+		return &OwningSIGMustBeWellFormed{file, sig}
+	}
+	return nil
+}
+
+// reNumberPrefix matches a path element that starts with a KEP number, such
+// as 0015-dry-run.md.
+var reNumberPrefix = regexp.MustCompile(`^(\d+)(-.*)$`)
+
+// PadNumbers returns filePath, a slash separated path, with the KEP number
+// prefix of every path element zero padded to width digits. Prefixes that
+// already have width digits or more, such as dates, are left as is.
+func PadNumbers(filePath string, width int) string {
+	elements := strings.Split(filePath, "/")
+	for i, element := range elements {
+		match := reNumberPrefix.FindStringSubmatch(element)
+		if match == nil || len(match[1]) >= width {
+			continue
+		}
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		elements[i] = fmt.Sprintf("%0*d%s", width, n, match[2])
+	}
+	return strings.Join(elements, "/")
+}
+
+type NumberMustBePadded struct {
+	path   string
+	padded string
+	width  int
+}
+
+func (n *NumberMustBePadded) Error() string {
+	return fmt.Sprintf("%s has a KEP number that is not zero padded to %d digits, rename it to %s", n.path, n.width, n.padded)
+}
+
+// ValidateNumberPadding checks that the KEP numbers in filePath, a slash
+// separated path, are zero padded to width digits, see PadNumbers.
+func ValidateNumberPadding(filePath string, width int) error {
+	if padded := PadNumbers(filePath, width); padded != filePath {
+		return &NumberMustBePadded{filePath, padded, width}
+	}
+	return nil
+}
+
+type ReferencesMustNotCycle struct {
+	key   string
+	cycle []string
+}
+
+func (r *ReferencesMustNotCycle) Error() string {
+	return fmt.Sprintf("%q references form a cycle: %s", r.key, strings.Join(r.cycle, " -> "))
+}
+
+func (r *ReferencesMustNotCycle) Key() string {
+	return r.key
+}
+
+// ValidateNoCycle reports cycle, a path of KEPs through key references that
+// ends where it started, if it is not empty.
+func ValidateNoCycle(key string, cycle []string) error {
+	if len(cycle) > 0 {
+		return &ReferencesMustNotCycle{key, cycle}
+	}
+	return nil
+}
+
+// SmartQuotes are the typographic quotes that pasting from documents tends
+// to leave in metadata values.
+const SmartQuotes = "“”„‟‘’‚‛"
+
+type ValueMustUseStraightQuotes struct {
+	key   string
+	value string
+}
+
+func (v *ValueMustUseStraightQuotes) Error() string {
+	return fmt.Sprintf("%q has typographic quotes, use straight quotes instead: %q", v.key, v.value)
+}
+
+func (v *ValueMustUseStraightQuotes) Key() string {
+	return v.key
+}
+
+// ValidateStraightQuotes checks that value, the value of key, has none of
+// the SmartQuotes.
+func ValidateStraightQuotes(key, value string) error {
+	if strings.ContainsAny(value, SmartQuotes) {
+		return &ValueMustUseStraightQuotes{key, value}
+	}
+	return nil
+}
+
+type KEPNumberMustBeInRange struct {
+	number int
+	max    int
+}
+
+func (k *KEPNumberMustBeInRange) Error() string {
+	return fmt.Sprintf("\"kep-number\" must be between 1 and %d but it is %d", k.max, k.number)
+}
+
+// ValidateKEPNumber checks that number, the kep-number of a KEP, is
+// positive and at most max.
+func ValidateKEPNumber(number, max int) error {
+	if number < 1 || number > max {
+		return &KEPNumberMustBeInRange{number, max}
+	}
+	return nil
+}
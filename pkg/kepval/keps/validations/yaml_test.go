@@ -220,3 +220,66 @@ func TestValidateStructureFailures(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateUnique(t *testing.T) {
+	testcases := []struct {
+		name      string
+		values    []string
+		expectErr bool
+	}{
+		{
+			name:   "empty list",
+			values: []string{},
+		},
+		{
+			name:   "distinct values",
+			values: []string{"sig-node", "sig-storage"},
+		},
+		{
+			name:      "exact duplicate",
+			values:    []string{"sig-node", "sig-storage", "sig-node"},
+			expectErr: true,
+		},
+		{
+			name:      "duplicate with different case",
+			values:    []string{"sig-node", "SIG-Node"},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateUnique("participating-sigs", tc.values)
+			if tc.expectErr && err == nil {
+				t.Fatal("expecting an error")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("did not expect an error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateTrackingIssue(t *testing.T) {
+	testcases := []struct {
+		value     string
+		expectErr bool
+	}{
+		{value: "1234"},
+		{value: "#1234"},
+		{value: "https://github.com/kubernetes/enhancements/issues/1234"},
+		{value: "https://github.com/kubernetes/enhancements/pull/1234", expectErr: true},
+		{value: "http://example.com/1234", expectErr: true},
+		{value: "TBD", expectErr: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.value, func(t *testing.T) {
+			err := ValidateTrackingIssue(tc.value)
+			if tc.expectErr && err == nil {
+				t.Fatal("expecting an error")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("did not expect an error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// KubeVersion is a Kubernetes release such as v1.19, as used by milestones.
+// Versions must be compared with Less rather than as strings, since v1.9 is
+// before v1.10.
+type KubeVersion struct {
+	Major int
+	Minor int
+}
+
+var reKubeVersion = regexp.MustCompile(`^v?(\d+)\.(\d+)$`)
+
+// ParseKubeVersion parses a version of the form vMAJOR.MINOR. The leading v
+// is optional.
+func ParseKubeVersion(s string) (KubeVersion, error) {
+	match := reKubeVersion.FindStringSubmatch(s)
+	if match == nil {
+		return KubeVersion{}, errors.Errorf("invalid version %q, must be of the form vMAJOR.MINOR", s)
+	}
+	major, err := strconv.Atoi(match[1])
+	if err != nil {
+		return KubeVersion{}, errors.Wrapf(err, "invalid major version in %q", s)
+	}
+	minor, err := strconv.Atoi(match[2])
+	if err != nil {
+		return KubeVersion{}, errors.Wrapf(err, "invalid minor version in %q", s)
+	}
+	return KubeVersion{Major: major, Minor: minor}, nil
+}
+
+// Less reports whether v is an earlier release than other.
+func (v KubeVersion) Less(other KubeVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	return v.Minor < other.Minor
+}
+
+func (v KubeVersion) String() string {
+	return fmt.Sprintf("v%d.%d", v.Major, v.Minor)
+}
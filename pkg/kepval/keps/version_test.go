@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"testing"
+
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestParseKubeVersion(t *testing.T) {
+	testcases := []struct {
+		input     string
+		expected  keps.KubeVersion
+		expectErr bool
+	}{
+		{input: "v1.19", expected: keps.KubeVersion{Major: 1, Minor: 19}},
+		{input: "1.9", expected: keps.KubeVersion{Major: 1, Minor: 9}},
+		{input: "v1", expectErr: true},
+		{input: "v1.19.0", expectErr: true},
+		{input: "latest", expectErr: true},
+		{input: "", expectErr: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.input, func(t *testing.T) {
+			v, err := keps.ParseKubeVersion(tc.input)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expecting an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error: %v", err)
+			}
+			if v != tc.expected {
+				t.Fatalf("expected %v but got %v", tc.expected, v)
+			}
+		})
+	}
+}
+
+func TestKubeVersionLess(t *testing.T) {
+	testcases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"v1.9", "v1.10", true},
+		{"v1.10", "v1.9", false},
+		{"v1.19", "v2.0", true},
+		{"v1.19", "v1.19", false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.a+" < "+tc.b, func(t *testing.T) {
+			a, _ := keps.ParseKubeVersion(tc.a)
+			b, _ := keps.ParseKubeVersion(tc.b)
+			if a.Less(b) != tc.expected {
+				t.Fatalf("expected %v but got %v", tc.expected, a.Less(b))
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps
+
+import (
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ToYAML writes the metadata of the proposals to w as a YAML list, in the
+// order of p, each encoded by Proposal.MarshalYAML. The fields of each
+// proposal are written in the order they are declared in Proposal, which
+// follows the KEP template, followed by any Extra keys sorted by name. Maps
+// such as milestone are also written with sorted keys, so the output only
+// changes when the metadata does. The body and Filename are not written.
+func (p Proposals) ToYAML(w io.Writer) error {
+	if len(p) == 0 {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+	out, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// MarshalYAML encodes the metadata of the proposal with the keys in the
+// order they are declared in Proposal, followed by the Extra keys sorted by
+// name. It is how the metadata of a KEP is written as YAML, by ToYAML as
+// well as to compare metadata in Parser.CheckFix, and decoding the result
+// gives back the same metadata. Values keep their types, such as
+// the kep-number as an integer and disable-supported as a bool. The title,
+// owning SIG, people, dates and status are always written, the other keys
+// only when they are set.
+func (p *Proposal) MarshalYAML() (interface{}, error) {
+	var metadata yaml.MapSlice
+	add := func(key string, value interface{}) {
+		metadata = append(metadata, yaml.MapItem{Key: key, Value: value})
+	}
+	addString := func(key, value string) {
+		if value != "" {
+			add(key, value)
+		}
+	}
+	addList := func(key string, values []string) {
+		if len(values) > 0 {
+			add(key, values)
+		}
+	}
+	if p.KEPNumber != nil {
+		add("kep-number", *p.KEPNumber)
+	}
+	add("title", p.Title)
+	add("authors", p.Authors)
+	add("owning-sig", p.OwningSIG)
+	add("participating-sigs", p.ParticipatingSIGs)
+	add("reviewers", p.Reviewers)
+	add("approvers", p.Approvers)
+	addString("editor", p.Editor)
+	add("creation-date", p.CreationDate)
+	add("last-updated", p.LastUpdated)
+	add("status", p.Status)
+	addList("see-also", p.SeeAlso)
+	addList("replaces", p.Replaces)
+	addList("superseded-by", p.SupersededBy)
+	addString("tracking-issue", p.TrackingIssue)
+	if len(p.FeatureGates) > 0 {
+		add("feature-gates", p.FeatureGates)
+	}
+	if p.DisableSupported {
+		add("disable-supported", true)
+	}
+	addString("stage", p.Stage)
+	addString("latest-milestone", p.LatestMilestone)
+	if len(p.Milestone) > 0 {
+		// yaml sorts the stages
+		add("milestone", p.Milestone)
+	}
+	keys := make([]string, 0, len(p.Extra))
+	for key := range p.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add(key, p.Extra[key])
+	}
+	return metadata, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keps_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/enhancements/pkg/kepval/keps"
+)
+
+func TestToYAML(t *testing.T) {
+	proposals := keps.Proposals{
+		{
+			Title:             "Server Side Apply",
+			Authors:           []string{"@jennybuckley", "@apelisse"},
+			OwningSIG:         "sig-api-machinery",
+			ParticipatingSIGs: []string{"sig-cli"},
+			Reviewers:         []string{"@lavalamp"},
+			Approvers:         []string{"@bgrant0607"},
+			CreationDate:      "2018-03-28",
+			LastUpdated:       "2019-07-12",
+			Status:            "implementable",
+			TrackingIssue:     "555",
+			FeatureGates:      []keps.FeatureGate{{Name: "ServerSideApply", Components: []string{"kube-apiserver"}}},
+			Stage:             "beta",
+			LatestMilestone:   "v1.16",
+			Milestone:         map[string]string{"beta": "v1.16", "alpha": "v1.14"},
+			Extra:             map[string]interface{}{"prr-approvers": []string{"@deads2k"}},
+			Filename:          "sig-api-machinery/0006-apply.md",
+			Contents:          "# Server Side Apply\n",
+		},
+		{
+			Title:     "Dry run",
+			Authors:   []string{"@apelisse"},
+			OwningSIG: "sig-api-machinery",
+			Status:    "implemented",
+		},
+	}
+	var out bytes.Buffer
+	if err := proposals.ToYAML(&out); err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile(filepath.Join("testdata", "proposals.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != string(golden) {
+		t.Fatalf("output does not match testdata/proposals.yaml, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := (keps.Proposals{}).ToYAML(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[]\n" {
+		t.Fatalf("expected an empty list but got %q", out.String())
+	}
+}
+
+func TestMarshalYAMLRoundTrip(t *testing.T) {
+	contents := `---
+kep-number: 15
+title: Dry run
+authors:
+  - "@apelisse"
+owning-sig: sig-api-machinery
+participating-sigs:
+  - sig-cli
+reviewers:
+  - "@lavalamp"
+approvers:
+  - "@deads2k"
+editor: "@jbeda"
+creation-date: 2018-06-21
+last-updated: 2019-01-02
+status: implemented
+see-also:
+  - "/keps/sig-api-machinery/0006-apply.md"
+tracking-issue: "576"
+feature-gates:
+  - name: DryRun
+    components:
+      - kube-apiserver
+disable-supported: true
+stage: stable
+latest-milestone: v1.18
+milestone:
+  alpha: v1.12
+  beta: v1.13
+  stable: v1.18
+prr-approvers:
+  - "@deads2k"
+---
+
+# Dry run
+`
+	parser := &keps.Parser{AllowedKeys: []string{"prr-approvers"}}
+	parsed := parser.Parse(strings.NewReader(contents))
+	if parsed.Error != nil {
+		t.Fatal(parsed.Error)
+	}
+	out, err := yaml.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typed := range []string{"kep-number: 15\n", "disable-supported: true\n"} {
+		if !strings.Contains(string(out), typed) {
+			t.Errorf("expected %q in the output, got:\n%s", typed, out)
+		}
+	}
+	var decoded keps.Proposal
+	if err := yaml.UnmarshalStrict(out, &decoded); err != nil {
+		t.Fatalf("could not decode the output: %v\n%s", err, out)
+	}
+	// only the metadata is written
+	expected := keps.Proposal{
+		KEPNumber: parsed.KEPNumber, Title: parsed.Title, Authors: parsed.Authors, OwningSIG: parsed.OwningSIG,
+		ParticipatingSIGs: parsed.ParticipatingSIGs, Reviewers: parsed.Reviewers, Approvers: parsed.Approvers,
+		Editor: parsed.Editor, CreationDate: parsed.CreationDate, LastUpdated: parsed.LastUpdated, Status: parsed.Status,
+		SeeAlso: parsed.SeeAlso, Replaces: parsed.Replaces, SupersededBy: parsed.SupersededBy, TrackingIssue: parsed.TrackingIssue,
+		FeatureGates: parsed.FeatureGates, DisableSupported: parsed.DisableSupported, Stage: parsed.Stage,
+		LatestMilestone: parsed.LatestMilestone, Milestone: parsed.Milestone, Extra: parsed.Extra,
+	}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Fatalf("expected %+v after a round trip but got %+v", expected, decoded)
+	}
+	again, err := yaml.Marshal(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(out) {
+		t.Fatalf("expected the same output when encoding again, got:\n%s\ninstead of:\n%s", again, out)
+	}
+}